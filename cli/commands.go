@@ -2,6 +2,9 @@ package cli
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 )
 
 // signature for cli commands
@@ -30,3 +33,55 @@ func (c *Commands) Register(name string, f func(*State, Command) error) {
 	c.CommandMap[name] = f
 
 }
+
+// splitFlags separates "--name value" and "--name=value" flags from the
+// positional arguments of a command, flags can appear anywhere in the arguments.
+// Boolean flags take no value, "--name" is stored as "true"
+func splitFlags(args []string, valueFlags []string, boolFlags ...string) (map[string]string, []string, error) {
+	known := map[string]bool{}
+	for _, name := range valueFlags {
+		known[name] = true
+	}
+	isBool := map[string]bool{}
+	for _, name := range boolFlags {
+		known[name] = true
+		isBool[name] = true
+	}
+
+	flags := map[string]string{}
+	positional := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !known[name] {
+			return nil, nil, fmt.Errorf("unknown flag --%s", name)
+		}
+		if isBool[name] {
+			enabled := true
+			if hasValue {
+				var err error
+				enabled, err = strconv.ParseBool(value)
+				if err != nil {
+					return nil, nil, fmt.Errorf("flag --%s takes no value or a boolean", name)
+				}
+			}
+			flags[name] = strconv.FormatBool(enabled)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		flags[name] = value
+	}
+
+	return flags, positional, nil
+}
@@ -3,8 +3,10 @@ package cli
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
+	"github.com/ManoloEsS/gator_cli/internal/config"
 	"github.com/ManoloEsS/gator_cli/internal/database"
 	"github.com/ManoloEsS/gator_cli/test"
 )
@@ -367,6 +369,84 @@ func TestNewState(t *testing.T) {
 	}
 }
 
+func TestSplitFlags(t *testing.T) {
+	tests := []struct {
+		name               string
+		args               []string
+		expectedFlags      map[string]string
+		expectedPositional []string
+		expectError        bool
+	}{
+		{
+			name:               "no flags",
+			args:               []string{"10"},
+			expectedFlags:      map[string]string{},
+			expectedPositional: []string{"10"},
+		},
+		{
+			name:               "flag with separate value after positional",
+			args:               []string{"10", "--tag", "go"},
+			expectedFlags:      map[string]string{"tag": "go"},
+			expectedPositional: []string{"10"},
+		},
+		{
+			name:               "flag with inline value before positional",
+			args:               []string{"--tag=web dev", "5"},
+			expectedFlags:      map[string]string{"tag": "web dev"},
+			expectedPositional: []string{"5"},
+		},
+		{
+			name:               "boolean flag",
+			args:               []string{"--json", "https://example.com"},
+			expectedFlags:      map[string]string{"json": "true"},
+			expectedPositional: []string{"https://example.com"},
+		},
+		{
+			name:               "boolean flag with inline value",
+			args:               []string{"--json=false", "--tag", "go"},
+			expectedFlags:      map[string]string{"json": "false", "tag": "go"},
+			expectedPositional: []string{},
+		},
+		{
+			name:        "boolean flag with invalid value",
+			args:        []string{"--json=maybe"},
+			expectError: true,
+		},
+		{
+			name:        "flag without value",
+			args:        []string{"--tag"},
+			expectError: true,
+		},
+		{
+			name:        "unknown flag",
+			args:        []string{"--color", "red"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, positional, err := splitFlags(tt.args, []string{"tag"}, "json")
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitFlags() error = %v", err)
+			}
+			if !reflect.DeepEqual(flags, tt.expectedFlags) {
+				t.Errorf("expected flags %v, got %v", tt.expectedFlags, flags)
+			}
+			if !reflect.DeepEqual(positional, tt.expectedPositional) {
+				t.Errorf("expected positional arguments %v, got %v", tt.expectedPositional, positional)
+			}
+		})
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
@@ -381,3 +461,59 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestNewHTTPClient(t *testing.T) {
+	retries := 3
+	tests := []struct {
+		name        string
+		cfg         config.HTTPConfig
+		expectError bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "all settings",
+			cfg: config.HTTPConfig{
+				Timeout:   "20s",
+				Retries:   &retries,
+				Backoff:   "500ms",
+				Proxy:     "http://proxy.corp:3128",
+				UserAgent: "Corp Reader",
+			},
+		},
+		{
+			name:        "invalid timeout",
+			cfg:         config.HTTPConfig{Timeout: "20"},
+			expectError: true,
+		},
+		{
+			name:        "invalid backoff",
+			cfg:         config.HTTPConfig{Backoff: "soon"},
+			expectError: true,
+		},
+		{
+			name:        "missing CA bundle",
+			cfg:         config.HTTPConfig{CABundle: "/nonexistent/ca.pem"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newHTTPClient(tt.cfg)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if client == nil {
+				t.Errorf("expected a client")
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+)
+
+// claimLease keeps a claimed feed from the other workers while it is
+// fetched, the claim is renewed every half lease until the fetch is done.
+// Feeds whose fetch failed are retried once it is over
+var claimLease = 15 * time.Minute
+
+// fetchReport is what a worker reports after reading a feed
+type fetchReport struct {
+	feed   database.Rssfeed
+	result *rss.FetchResult
+	err    error
+}
+
+// Handler that fetches the due feeds every interval with a pool of workers
+func HandlerAgg(s *State, cmd Command) error {
+	flags, args, err := splitFlags(cmd.Arguments, []string{"workers"})
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("usage %s <duration> [--workers <n>]", cmd.Name)
+	}
+	time_between_reqs := args[0]
+	duration, err := time.ParseDuration(time_between_reqs)
+	if err != nil {
+		return fmt.Errorf("usage eg: 1s (s: second, m: minute, h: hour): %w", err)
+	}
+	workers := 1
+	if flags["workers"] != "" {
+		workers, err = strconv.Atoi(flags["workers"])
+		if err != nil || workers < 1 {
+			return fmt.Errorf("--workers must be a positive number, got %q\n", flags["workers"])
+		}
+	}
+
+	ticker := time.NewTicker(duration)
+	defer ticker.Stop()
+	fmt.Printf("Collecting feeds every %s with %d workers\n", time_between_reqs, workers)
+
+	// the workers only fetch, their reports are logged here one at a time
+	for report := range aggregate(context.Background(), s, ticker.C, workers) {
+		logFetch(report.feed, report.result, report.err)
+	}
+	return nil
+}
+
+// aggregate starts the workers that fetch the due feeds right away and on
+// every tick. Their reports are sent on the returned channel, which is
+// closed once ctx is done and every worker stopped
+func aggregate(ctx context.Context, s *State, ticks <-chan time.Time, workers int) <-chan fetchReport {
+	wake := make(chan time.Time, workers)
+	reports := make(chan fetchReport)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			aggWorker(ctx, s, wake, reports)
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(reports)
+	}()
+
+	go func() {
+		now := time.Now().UTC()
+		for {
+			// every worker is woken, busy workers find the wake up when they
+			// are done and look for feeds that became due in the meantime
+			for range workers {
+				select {
+				case wake <- now:
+				default:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case tick := <-ticks:
+				now = tick.UTC()
+			}
+		}
+	}()
+
+	return reports
+}
+
+// aggWorker fetches the feeds due when it is woken until ctx is done
+func aggWorker(ctx context.Context, s *State, wake <-chan time.Time, reports chan<- fetchReport) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case due := <-wake:
+			fetchDueFeeds(ctx, s, due, reports)
+		}
+	}
+}
+
+// fetchDueFeeds claims and fetches the feeds due at due until none is left.
+// Feeds rescheduled while fetching are due after it, so they wait for the
+// next tick instead of being fetched over and over
+func fetchDueFeeds(ctx context.Context, s *State, due time.Time, reports chan<- fetchReport) {
+	for ctx.Err() == nil {
+		claimedUntil := claimEnd()
+		feed, err := s.Db.ClaimNextFeedToFetch(ctx, database.ClaimNextFeedToFetchParams{
+			Now:          due,
+			ClaimedUntil: claimedUntil,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			log.Printf("couldn't claim next feed to fetch: %v", err)
+			return
+		}
+
+		release := holdClaim(ctx, s, feed, claimedUntil)
+		result, err := fetchFeed(s, feed)
+		release()
+		select {
+		case reports <- fetchReport{feed: feed, result: result, err: err}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// holdClaim renews the claim of the feed until the returned func is called,
+// so a fetch that outlives the lease, like one downloading many full
+// articles, isn't claimed by another worker in the meantime
+func holdClaim(ctx context.Context, s *State, feed database.Rssfeed, claimedUntil time.Time) (release func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ticker := time.NewTicker(claimLease / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			renewedUntil := claimEnd()
+			renewed, err := s.Db.RenewFeedClaim(ctx, database.RenewFeedClaimParams{
+				RenewedUntil: renewedUntil,
+				ID:           feed.ID,
+				ClaimedUntil: claimedUntil,
+			})
+			if err != nil {
+				log.Printf("couldn't renew claim of feed %s: %v", feed.Name, err)
+				return
+			}
+			// the fetch already rescheduled the feed
+			if renewed == 0 {
+				return
+			}
+			claimedUntil = renewedUntil
+		}
+	})
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// claimEnd returns when a claim made now is over. The database keeps
+// microseconds, so the claim is cut to them to be found again when renewed
+func claimEnd() time.Time {
+	return time.Now().UTC().Add(claimLease).Truncate(time.Microsecond)
+}
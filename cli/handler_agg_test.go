@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+	"github.com/ManoloEsS/gator_cli/test"
+	"github.com/google/uuid"
+)
+
+func TestFetchDueFeeds_Schedule(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/hourly" {
+			w.Write([]byte(`<rss><channel><title>Hourly</title><ttl>60</ttl></channel></rss>`))
+			return
+		}
+		w.Write([]byte(`<rss><channel><title>Busy</title></channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	hourlyURL := server.URL + "/hourly"
+	mockDb.Feeds[hourlyURL] = database.Rssfeed{ID: uuid.New(), Name: "hourly", Url: hourlyURL}
+
+	reports := make(chan fetchReport, 10)
+	fetchDue := func() {
+		fetchDueFeeds(context.Background(), state, time.Now().UTC(), reports)
+	}
+
+	before := time.Now().UTC()
+	fetchDue()
+
+	feed := mockDb.Feeds[hourlyURL]
+	if !feed.NextFetchAt.Valid {
+		t.Fatalf("expected the next fetch to be scheduled")
+	}
+	if delay := feed.NextFetchAt.Time.Sub(before); delay < time.Hour || delay > time.Hour+time.Minute {
+		t.Errorf("expected the next fetch about an hour from now, got %v", delay)
+	}
+
+	fetchDue()
+	if requests != 1 {
+		t.Errorf("expected a feed that isn't due to be skipped, got %d requests", requests)
+	}
+
+	busyURL := server.URL + "/busy"
+	mockDb.Feeds[busyURL] = database.Rssfeed{ID: uuid.New(), Name: "busy", Url: busyURL}
+	fetchDue()
+	if requests != 2 {
+		t.Fatalf("expected the due feed to be fetched, got %d requests", requests)
+	}
+	if busy := mockDb.Feeds[busyURL]; !busy.NextFetchAt.Valid || busy.NextFetchAt.Time.After(time.Now().UTC()) {
+		t.Errorf("expected a feed without schedule hints to stay due, got %+v", busy.NextFetchAt)
+	}
+	if len(reports) != 2 {
+		t.Errorf("expected a report for every fetch, got %d", len(reports))
+	}
+}
+
+func TestScheduleNextFetch_NotModified(t *testing.T) {
+	lastFetch := time.Now().UTC().Add(-time.Minute)
+	tests := []struct {
+		name          string
+		lastFetchedAt sql.NullTime
+		nextFetchAt   sql.NullTime
+		expectedDelay time.Duration
+	}{
+		{
+			name:          "previous schedule kept",
+			lastFetchedAt: newNullTime(lastFetch),
+			nextFetchAt:   newNullTime(lastFetch.Add(6 * time.Hour)),
+			expectedDelay: 6 * time.Hour,
+		},
+		{
+			name:          "feed without schedule hints",
+			lastFetchedAt: newNullTime(lastFetch),
+			nextFetchAt:   newNullTime(lastFetch),
+			expectedDelay: 0,
+		},
+		{
+			name:          "never scheduled",
+			lastFetchedAt: newNullTime(lastFetch),
+			expectedDelay: unknownFetchDelay,
+		},
+		{
+			name:          "never fetched",
+			expectedDelay: unknownFetchDelay,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := test.NewMockDb()
+			feed := database.Rssfeed{
+				ID:            uuid.New(),
+				Name:          "cached",
+				Url:           "https://example.com/feed.xml",
+				LastFetchedAt: tt.lastFetchedAt,
+				NextFetchAt:   tt.nextFetchAt,
+			}
+			mockDb.Feeds[feed.Url] = feed
+
+			before := time.Now().UTC()
+			scheduleNextFetch(mockDb, feed, &rss.FetchResult{NotModified: true})
+			next := mockDb.Feeds[feed.Url].NextFetchAt
+			if !next.Valid {
+				t.Fatalf("expected the next fetch to be scheduled")
+			}
+			if delay := next.Time.Sub(before); delay < tt.expectedDelay || delay > tt.expectedDelay+time.Minute {
+				t.Errorf("expected the next fetch in %v, got %v", tt.expectedDelay, delay)
+			}
+		})
+	}
+}
+
+func TestFetchDueFeeds_OutlivesLease(t *testing.T) {
+	defaultLease := claimLease
+	claimLease = 40 * time.Millisecond
+	defer func() { claimLease = defaultLease }()
+
+	started := make(chan struct{})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		close(started)
+		// the fetch takes several leases
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`<rss><channel><title>Slow</title><ttl>60</ttl></channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	mockDb.Feeds[server.URL] = database.Rssfeed{ID: uuid.New(), Name: "slow", Url: server.URL}
+
+	reports := make(chan fetchReport, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fetchDueFeeds(context.Background(), state, time.Now().UTC(), reports)
+	}()
+
+	<-started
+	time.Sleep(3 * claimLease)
+	_, err := mockDb.ClaimNextFeedToFetch(context.Background(), database.ClaimNextFeedToFetchParams{
+		Now:          time.Now().UTC(),
+		ClaimedUntil: claimEnd(),
+	})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected the feed to stay claimed while it is fetched, got %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the fetch")
+	}
+	if report := <-reports; report.err != nil {
+		t.Fatalf("expected the feed to be fetched, got %v", report.err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the feed to be fetched once, got %d requests", requests)
+	}
+	// renewing the claim doesn't undo the schedule of the fetch
+	feed := mockDb.Feeds[server.URL]
+	if !feed.NextFetchAt.Valid || time.Until(feed.NextFetchAt.Time) < 59*time.Minute {
+		t.Errorf("expected the next fetch about an hour from now, got %+v", feed.NextFetchAt)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		// slow feeds keep the workers busy at the same time
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`<rss><channel><title>Feed</title><ttl>60</ttl>
+<item><title>Post</title><link>https://example.com` + r.URL.Path + `</link></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	const feeds = 12
+	for i := range feeds {
+		feedUrl := fmt.Sprintf("%s/feed-%d", server.URL, i)
+		mockDb.Feeds[feedUrl] = database.Rssfeed{ID: uuid.New(), Name: fmt.Sprintf("feed %d", i), Url: feedUrl}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	reports := aggregate(ctx, state, ticks, 4)
+
+	for range feeds {
+		select {
+		case report := <-reports:
+			if report.err != nil {
+				t.Errorf("expected feed %s to be fetched, got %v", report.feed.Name, report.err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the workers")
+		}
+	}
+
+	// the feeds are scheduled an hour ahead, another tick finds nothing due
+	ticks <- time.Now()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	for report := range reports {
+		t.Errorf("expected no feed to be fetched again, got %s", report.feed.Name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != feeds {
+		t.Errorf("expected every feed to be fetched, got %d of %d", len(requests), feeds)
+	}
+	for path, count := range requests {
+		if count != 1 {
+			t.Errorf("expected %s to be fetched once, got %d", path, count)
+		}
+	}
+	if len(mockDb.Posts) != feeds {
+		t.Errorf("expected a post per feed, got %d", len(mockDb.Posts))
+	}
+}
+
+func TestHandlerAgg_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no interval", args: []string{}},
+		{name: "invalid interval", args: []string{"soon"}},
+		{name: "no workers", args: []string{"30s", "--workers", "0"}},
+		{name: "invalid workers", args: []string{"30s", "--workers", "many"}},
+		{name: "unknown flag", args: []string{"30s", "--threads", "8"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &State{
+				Db:  test.NewMockDb(),
+				Cfg: &test.MockCfg{},
+			}
+			if err := HandlerAgg(state, Command{Name: "agg", Arguments: tt.args}); err == nil {
+				t.Errorf("expected error but got none")
+			}
+		})
+	}
+}
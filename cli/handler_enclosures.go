@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+	"github.com/google/uuid"
+)
+
+// Handler that lists the media files (podcast episodes etc.) attached to the
+// posts of the feeds the user follows
+func HandlerListEnclosures(s *State, cmd Command, user database.User) error {
+	var enclosuresNum int32 = 10
+	if len(cmd.Arguments) == 1 {
+		if n, err := strconv.Atoi(cmd.Arguments[0]); err == nil {
+			enclosuresNum = int32(n)
+		}
+	}
+
+	enclosures, err := s.Db.GetEnclosuresForUser(context.Background(), database.GetEnclosuresForUserParams{
+		UserID: user.ID,
+		Limit:  enclosuresNum,
+	})
+	if err != nil {
+		return fmt.Errorf("Couldn't get enclosures for user %s: %w", user.Name, err)
+	}
+	if len(enclosures) == 0 {
+		fmt.Println("No enclosures found in the feeds this user follows.")
+		return nil
+	}
+
+	for _, item := range enclosures {
+		fmt.Printf("%s from %s\n", item.PublishedAt.Time.Format("Mon Jan 2"), item.FeedName)
+		fmt.Printf("---%s---\n", item.PostTitle)
+		if details := describeEnclosure(database.PostEnclosure{
+			Length:      item.Length,
+			MimeType:    item.MimeType,
+			Duration:    item.Duration,
+			Episode:     item.Episode,
+			Season:      item.Season,
+			EpisodeType: item.EpisodeType,
+		}); details != "" {
+			fmt.Printf("   %s\n", details)
+		}
+		fmt.Printf("Media: %s\n", item.Url)
+		fmt.Println("====================================")
+	}
+	return nil
+}
+
+// storeEnclosures saves the enclosures of a feed item along with its iTunes episode metadata
+func storeEnclosures(db DBInterface, postID uuid.UUID, item rss.RSSItem) {
+	for _, enclosure := range item.Enclosures {
+		if enclosure.URL == "" {
+			continue
+		}
+		_, err := db.CreatePostEnclosure(context.Background(), database.CreatePostEnclosureParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+			PostID:      postID,
+			Url:         enclosure.URL,
+			Length:      newNullInt64(enclosure.Length),
+			MimeType:    newNullString(strings.TrimSpace(enclosure.Type)),
+			Duration:    newNullString(strings.TrimSpace(item.Duration)),
+			Episode:     newNullInt32(item.Episode),
+			Season:      newNullInt32(item.Season),
+			EpisodeType: newNullString(strings.TrimSpace(item.EpisodeType)),
+		})
+		if err != nil {
+			log.Printf("couldn't add enclosure %s to database: %v", enclosure.URL, err)
+		}
+	}
+}
+
+// describeEnclosure formats the known metadata of an enclosure, eg: "(S2 E12, audio/mpeg, 23.8 MB, 41:38)"
+func describeEnclosure(enclosure database.PostEnclosure) string {
+	var details []string
+	switch {
+	case enclosure.Season.Valid && enclosure.Episode.Valid:
+		details = append(details, fmt.Sprintf("S%d E%d", enclosure.Season.Int32, enclosure.Episode.Int32))
+	case enclosure.Episode.Valid:
+		details = append(details, fmt.Sprintf("E%d", enclosure.Episode.Int32))
+	}
+	if enclosure.EpisodeType.Valid && enclosure.EpisodeType.String != "full" {
+		details = append(details, enclosure.EpisodeType.String)
+	}
+	if enclosure.MimeType.Valid {
+		details = append(details, enclosure.MimeType.String)
+	}
+	if enclosure.Length.Valid {
+		details = append(details, fmt.Sprintf("%.1f MB", float64(enclosure.Length.Int64)/(1024*1024)))
+	}
+	if enclosure.Duration.Valid {
+		details = append(details, enclosure.Duration.String)
+	}
+
+	if len(details) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(details, ", ") + ")"
+}
+
+func newNullInt64(s string) sql.NullInt64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n <= 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{
+		Int64: n,
+		Valid: true,
+	}
+}
+
+func newNullInt32(s string) sql.NullInt32 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 32)
+	if err != nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{
+		Int32: int32(n),
+		Valid: true,
+	}
+}
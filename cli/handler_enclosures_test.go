@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/test"
+	"github.com/google/uuid"
+)
+
+func TestScrapeFeed_StoresEnclosures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"><channel><title>Gator Talk</title>
+<item>
+  <title>Episode 12</title>
+  <link>https://example.com/podcast/12</link>
+  <enclosure url="https://cdn.example.com/ep12.mp3" length="24986239" type="audio/mpeg"/>
+  <itunes:duration>41:38</itunes:duration>
+  <itunes:episode>12</itunes:episode>
+</item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	scrapeFeed(state, database.Rssfeed{ID: uuid.New(), Name: "gator talk", Url: server.URL})
+
+	if len(mockDb.Posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(mockDb.Posts))
+	}
+	if len(mockDb.Enclosures) != 1 {
+		t.Fatalf("expected 1 enclosure, got %d", len(mockDb.Enclosures))
+	}
+
+	enclosure := mockDb.Enclosures[0]
+	if enclosure.PostID != mockDb.Posts[0].ID {
+		t.Errorf("expected enclosure to be linked to post %v, got %v", mockDb.Posts[0].ID, enclosure.PostID)
+	}
+	if enclosure.Url != "https://cdn.example.com/ep12.mp3" {
+		t.Errorf("expected enclosure url to be stored, got %q", enclosure.Url)
+	}
+	if enclosure.Length.Int64 != 24986239 || enclosure.MimeType.String != "audio/mpeg" {
+		t.Errorf("expected length and type to be stored, got %v %v", enclosure.Length, enclosure.MimeType)
+	}
+	if enclosure.Duration.String != "41:38" || enclosure.Episode.Int32 != 12 || enclosure.Season.Valid {
+		t.Errorf("expected itunes metadata to be stored, got %+v", enclosure)
+	}
+}
+
+func TestDescribeEnclosure(t *testing.T) {
+	tests := []struct {
+		name      string
+		enclosure database.PostEnclosure
+		expected  string
+	}{
+		{
+			name:      "no metadata",
+			enclosure: database.PostEnclosure{},
+			expected:  "",
+		},
+		{
+			name: "podcast episode",
+			enclosure: database.PostEnclosure{
+				Length:      sql.NullInt64{Int64: 24986239, Valid: true},
+				MimeType:    sql.NullString{String: "audio/mpeg", Valid: true},
+				Duration:    sql.NullString{String: "41:38", Valid: true},
+				Episode:     sql.NullInt32{Int32: 12, Valid: true},
+				Season:      sql.NullInt32{Int32: 2, Valid: true},
+				EpisodeType: sql.NullString{String: "full", Valid: true},
+			},
+			expected: "(S2 E12, audio/mpeg, 23.8 MB, 41:38)",
+		},
+		{
+			name: "trailer without season",
+			enclosure: database.PostEnclosure{
+				Episode:     sql.NullInt32{Int32: 1, Valid: true},
+				EpisodeType: sql.NullString{String: "trailer", Valid: true},
+			},
+			expected: "(E1, trailer)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeEnclosure(tt.enclosure); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+)
+
+// Handler that reads a feed from the standard input and stores its posts
+// in an existing feed, for feeds generated by scripts
+func HandlerIngest(s *State, cmd Command) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("usage: %s <feed-name>\n", cmd.Name)
+	}
+
+	feed, err := feedByName(s, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+
+	err = s.Db.MarkFeedFetched(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("Couldn't mark feed %s fetched: %w", feed.Name, err)
+	}
+
+	// relative links of the piped feed are resolved against the feed's url
+	baseUrl, _ := url.Parse(feed.Url)
+	source := rss.ReaderSource{
+		Reader:  stdin(s),
+		BaseURL: baseUrl,
+	}
+	fetchResult, err := ingestFeed(s, feed, source)
+	if err != nil {
+		return fmt.Errorf("Couldn't read feed from standard input: %w", err)
+	}
+	scheduleNextFetch(s.Db, feed, fetchResult)
+	logFetch(feed, fetchResult, nil)
+	return nil
+}
+
+func stdin(s *State) io.Reader {
+	if s.Stdin != nil {
+		return s.Stdin
+	}
+	return os.Stdin
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/test"
+	"github.com/google/uuid"
+)
+
+const ingestFeedXML = `<rss><channel><title>Script</title>
+<item><title>First</title><link>/first</link><guid>1</guid></item>
+<item><title>Second</title><link>https://example.com/second</link><guid>2</guid></item>
+</channel></rss>`
+
+func TestHandlerIngest(t *testing.T) {
+	tests := []struct {
+		name          string
+		arguments     []string
+		input         string
+		feeds         []string
+		expectedPosts int
+		expectError   bool
+	}{
+		{
+			name:          "piped feed",
+			arguments:     []string{"script"},
+			input:         ingestFeedXML,
+			feeds:         []string{"script"},
+			expectedPosts: 2,
+		},
+		{
+			name:        "invalid feed",
+			arguments:   []string{"script"},
+			input:       "not a feed",
+			feeds:       []string{"script"},
+			expectError: true,
+		},
+		{
+			name:        "unknown feed",
+			arguments:   []string{"script"},
+			input:       ingestFeedXML,
+			expectError: true,
+		},
+		{
+			name:        "ambiguous feed name",
+			arguments:   []string{"script"},
+			input:       ingestFeedXML,
+			feeds:       []string{"script", "script"},
+			expectError: true,
+		},
+		{
+			name:        "missing feed name",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := test.NewMockDb()
+			for i, name := range tt.feeds {
+				url := "https://example.com/feed" + strings.Repeat("/", i)
+				mockDb.Feeds[url] = database.Rssfeed{ID: uuid.New(), Name: name, Url: url}
+			}
+			state := &State{
+				Db:    mockDb,
+				Cfg:   &test.MockCfg{},
+				Stdin: strings.NewReader(tt.input),
+			}
+
+			err := HandlerIngest(state, Command{Name: "ingest", Arguments: tt.arguments})
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(mockDb.Posts) != tt.expectedPosts {
+				t.Fatalf("expected %d posts, got %d", tt.expectedPosts, len(mockDb.Posts))
+			}
+			if got := mockDb.Posts[0].Url; got != "https://example.com/first" {
+				t.Errorf("expected relative link resolved against the feed url, got %q", got)
+			}
+		})
+	}
+}
+
+func TestScrapeFeed_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	if err := os.WriteFile(path, []byte(ingestFeedXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mockDb := test.NewMockDb()
+	feedUrl := "file://" + filepath.ToSlash(path)
+	mockDb.Feeds[feedUrl] = database.Rssfeed{ID: uuid.New(), Name: "script", Url: feedUrl}
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+
+	scrapeFeed(state, mockDb.Feeds[feedUrl])
+	if len(mockDb.Posts) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(mockDb.Posts))
+	}
+	if !mockDb.Feeds[feedUrl].LastModified.Valid {
+		t.Error("expected the file modification time to be kept as Last-Modified")
+	}
+
+	// the unchanged file is not read again
+	scrapeFeed(state, mockDb.Feeds[feedUrl])
+	if len(mockDb.Posts) != 2 {
+		t.Errorf("expected no new posts from the unchanged file, got %d posts", len(mockDb.Posts))
+	}
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"path/filepath"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Handler that turns the newsletters of a Maildir or mbox into feeds, one
+// per sender, and imports their messages as posts. Running it again adds
+// the new senders, the aggregator keeps the feeds up to date
+func HandlerNewsletters(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("usage: %s <maildir|mbox>\n", cmd.Name)
+	}
+
+	path, err := filepath.Abs(cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Couldn't find mailbox %s: %w", cmd.Arguments[0], err)
+	}
+	senders, err := rss.MailSenders(path, feedLimits(s))
+	if err != nil {
+		return fmt.Errorf("Couldn't read mailbox %s: %w", path, err)
+	}
+	if len(senders) == 0 {
+		fmt.Printf("No newsletters found in %s\n", path)
+		return nil
+	}
+
+	config, err := json.Marshal(rss.MailConfig{Path: path})
+	if err != nil {
+		return fmt.Errorf("Couldn't encode mailbox config: %w", err)
+	}
+	for _, sender := range senders {
+		feed, err := newsletterFeed(s, user, sender, config)
+		if err != nil {
+			log.Printf("couldn't add newsletters of %s: %v", sender.Address, err)
+			continue
+		}
+		scrapeFeed(s, feed)
+	}
+	return nil
+}
+
+// newsletterFeed returns the feed of the sender followed by the user,
+// adding it the first time the sender is seen
+func newsletterFeed(s *State, user database.User, sender mail.Address, config json.RawMessage) (database.Rssfeed, error) {
+	feedUrl := rss.MailURL(sender.Address)
+	feed, err := s.Db.GetFeedByUrl(context.Background(), feedUrl)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.Rssfeed{}, fmt.Errorf("couldn't get feed: %w", err)
+	}
+	if err != nil {
+		feedName := sender.Name
+		if feedName == "" {
+			feedName = sender.Address
+		}
+		_, err = s.Db.CreateRSSFeed(context.Background(), database.CreateRSSFeedParams{
+			ID:           uuid.New(),
+			CreatedAt:    time.Now().UTC(),
+			UpdatedAt:    time.Now().UTC(),
+			Name:         feedName,
+			Url:          feedUrl,
+			UserID:       user.ID,
+			SourceType:   "mail",
+			SourceConfig: config,
+		})
+		if err != nil {
+			return database.Rssfeed{}, fmt.Errorf("couldn't add feed: %w", err)
+		}
+		feed, err = s.Db.GetFeedByUrl(context.Background(), feedUrl)
+		if err != nil {
+			return database.Rssfeed{}, fmt.Errorf("couldn't get added feed: %w", err)
+		}
+		fmt.Printf("\"%s\" succesfully added to %s's feed\n", feed.Name, user.Name)
+	}
+
+	_, err = s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	// the user already follows the feed (user_id, feed_id)
+	if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+		err = nil
+	}
+	if err != nil {
+		return database.Rssfeed{}, fmt.Errorf("couldn't follow feed: %w", err)
+	}
+	return feed, nil
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/test"
+	"github.com/google/uuid"
+)
+
+const testMbox = `From weekly@swamp.example Mon Dec 30 10:00:00 2024
+From: Swamp Weekly <weekly@swamp.example>
+Subject: Gators issue 11
+Date: Mon, 30 Dec 2024 10:00:00 +0000
+Message-ID: <issue-11@swamp.example>
+
+Issue 11.
+
+From weekly@swamp.example Mon Jan  6 10:00:00 2025
+From: Swamp Weekly <weekly@swamp.example>
+Subject: Gators issue 12
+Date: Mon, 06 Jan 2025 10:00:00 +0000
+Message-ID: <issue-12@swamp.example>
+Content-Type: text/html
+
+<p>Issue 12</p>
+
+From herons@birds.example Sun Jan  5 08:00:00 2025
+From: herons@birds.example
+Subject: Heron season
+Date: Sun, 05 Jan 2025 08:00:00 +0000
+Message-ID: <herons-1@birds.example>
+
+Herons are back.
+`
+
+func TestHandlerNewsletters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newsletters.mbox")
+	if err := os.WriteFile(path, []byte(testMbox), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	user := database.User{ID: uuid.New(), Name: "testuser"}
+	cmd := Command{Name: "newsletters", Arguments: []string{path}}
+
+	if err := HandlerNewsletters(state, cmd, user); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	expectedFeeds := map[string]string{
+		"mailto:weekly@swamp.example": "Swamp Weekly",
+		"mailto:herons@birds.example": "herons@birds.example",
+	}
+	if len(mockDb.Feeds) != len(expectedFeeds) {
+		t.Fatalf("expected a feed per sender, got %v", mockDb.Feeds)
+	}
+	for url, name := range expectedFeeds {
+		feed, exists := mockDb.Feeds[url]
+		if !exists || feed.Name != name || feed.SourceType != "mail" {
+			t.Errorf("expected mail feed %q at %s, got %+v", name, url, feed)
+		}
+	}
+	if len(mockDb.Posts) != 3 {
+		t.Fatalf("expected a post per message, got %d", len(mockDb.Posts))
+	}
+	for _, post := range mockDb.Posts {
+		if post.Guid == "issue-12@swamp.example" && (post.Title != "Gators issue 12" || post.Description.String != "<p>Issue 12</p>") {
+			t.Errorf("expected the newsletter as a post, got %+v", post)
+		}
+	}
+
+	if err := HandlerNewsletters(state, cmd, user); err != nil {
+		t.Fatalf("expected no error importing again but got: %v", err)
+	}
+	if len(mockDb.Feeds) != 2 || len(mockDb.Posts) != 3 {
+		t.Errorf("expected messages to be imported once, got %d feeds and %d posts", len(mockDb.Feeds), len(mockDb.Posts))
+	}
+
+	cmd.Arguments = []string{filepath.Join(t.TempDir(), "missing")}
+	if err := HandlerNewsletters(state, cmd, user); err == nil {
+		t.Errorf("expected an error for a missing mailbox")
+	}
+}
+
+func TestNewsletterFeed_DatabaseError(t *testing.T) {
+	mockDb := test.NewMockDb()
+	mockDb.GetFeedError = errors.New("connection refused")
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	user := database.User{ID: uuid.New(), Name: "testuser"}
+
+	_, err := newsletterFeed(state, user, mail.Address{Address: "weekly@swamp.example"}, nil)
+	if !errors.Is(err, mockDb.GetFeedError) {
+		t.Errorf("expected the database error, got %v", err)
+	}
+	if len(mockDb.Feeds) != 0 {
+		t.Errorf("expected no feed to be added, got %v", mockDb.Feeds)
+	}
+}
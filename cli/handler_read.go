@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/readability"
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+	"github.com/google/uuid"
+)
+
+// Handler that shows the full article of a post, downloading it from the
+// post's link when it wasn't stored yet. Posts are named by id or url
+func HandlerRead(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("usage: %s <post-id|url>\n", cmd.Name)
+	}
+
+	postName := cmd.Arguments[0]
+	post, err := s.Db.GetPostForUser(context.Background(), database.GetPostForUserParams{
+		UserID: user.ID,
+		Post:   postName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no post %s in the feeds %s follows\n", postName, user.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("Couldn't get post %s: %w", postName, err)
+	}
+
+	text := post.Article.String
+	if !post.Article.Valid && post.Url != "" {
+		text, err = fetchArticle(s, post.ID, post.Url)
+		if err != nil {
+			log.Printf("couldn't get full article of post %s: %v", post.Title, err)
+		}
+	}
+	// pages without an article fall back to what the feed provided
+	if text == "" {
+		body := post.Description.String
+		if post.Content.Valid && strings.TrimSpace(post.Content.String) != "" {
+			body = post.Content.String
+		}
+		text = StripHTML(body)
+	}
+
+	published := post.PublishedAt.Time.Format("Mon Jan 2")
+	if post.PublishedAtGuessed {
+		published = "~" + published
+	}
+	fmt.Printf("---%s---\n", post.Title)
+	fmt.Printf("%s from %s\n", published, post.FeedName)
+	if post.Author.Valid {
+		fmt.Printf("By %s\n", post.Author.String)
+	}
+	fmt.Printf("\n%s\n\n", text)
+	if post.Url != "" {
+		fmt.Printf("Link: %s\n", post.Url)
+	}
+	return nil
+}
+
+// Handler that turns the full article mode of a feed the user follows on or
+// off, new posts of feeds in that mode are stored with the article their
+// link points to
+func HandlerFullArticle(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) < 2 || (cmd.Arguments[1] != "on" && cmd.Arguments[1] != "off") {
+		return fmt.Errorf("usage: %s <feed-name> <on|off>\n", cmd.Name)
+	}
+
+	feed, err := followedFeedByName(s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+
+	enabled := cmd.Arguments[1] == "on"
+	err = s.Db.SetFeedFullArticle(context.Background(), database.SetFeedFullArticleParams{
+		ID:               feed.ID,
+		FetchFullArticle: enabled,
+	})
+	if err != nil {
+		return fmt.Errorf("Couldn't update feed %s: %w", feed.Name, err)
+	}
+
+	if enabled {
+		fmt.Printf("Full articles of new posts in %s will be downloaded\n", feed.Name)
+	} else {
+		fmt.Printf("Full articles of %s will only be downloaded by read\n", feed.Name)
+	}
+	return nil
+}
+
+// fetchArticle downloads the page the post links to and stores the text of
+// its main content with the post. Pages that have no article or aren't HTML
+// are stored with an empty article so they aren't downloaded again, only
+// failed downloads are retried
+func fetchArticle(s *State, postID uuid.UUID, postUrl string) (string, error) {
+	var article readability.Article
+	page, err := httpClient(s).FetchPage(context.Background(), postUrl, feedLimits(s))
+	if err == nil {
+		article, err = readability.Extract(bytes.NewReader(page.Body))
+	}
+	if err != nil && !errors.Is(err, readability.ErrNoArticle) && !errors.Is(err, rss.ErrNotHTML) {
+		return "", err
+	}
+
+	storeErr := s.Db.SetPostArticle(context.Background(), database.SetPostArticleParams{
+		ID:      postID,
+		Article: sql.NullString{String: article.Text, Valid: true},
+	})
+	if storeErr != nil {
+		return "", fmt.Errorf("couldn't store article: %w", storeErr)
+	}
+	return article.Text, err
+}
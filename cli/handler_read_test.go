@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/test"
+	"github.com/google/uuid"
+)
+
+var articleParagraph = strings.Repeat("The whole article is only on the site, the feed has a teaser. ", 5)
+
+// articleServer serves a feed whose items link to article pages
+func articleServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte(`<rss><channel><title>Teasers</title>
+<item><title>Long read</title><link>/posts/long</link><guid>1</guid><description>Teaser.</description></item>
+<item><title>Video</title><link>/posts/video</link><guid>2</guid><description>Watch it.</description></item>
+</channel></rss>`))
+		case "/posts/long":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><body><nav><a href="/">Home</a></nav>
+<article><p>` + articleParagraph + `</p></article></body></html>`))
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><body><video src="/clip.mp4"></video></body></html>`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScrapeFeed_FullArticle(t *testing.T) {
+	server := articleServer(t)
+
+	tests := []struct {
+		name             string
+		fullArticle      bool
+		expectedArticles map[string]string
+	}{
+		{
+			name:             "mode off",
+			expectedArticles: map[string]string{},
+		},
+		{
+			name:        "mode on",
+			fullArticle: true,
+			expectedArticles: map[string]string{
+				"Long read": strings.TrimSpace(articleParagraph),
+				// stored empty so the page isn't downloaded again
+				"Video": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := test.NewMockDb()
+			state := &State{
+				Db:  mockDb,
+				Cfg: &test.MockCfg{},
+			}
+			feed := database.Rssfeed{ID: uuid.New(), Name: "teasers", Url: server.URL + "/feed.xml", FetchFullArticle: tt.fullArticle}
+
+			scrapeFeed(state, feed)
+			if len(mockDb.Posts) != 2 {
+				t.Fatalf("expected 2 posts, got %d", len(mockDb.Posts))
+			}
+			for _, post := range mockDb.Posts {
+				expected, ok := tt.expectedArticles[post.Title]
+				if post.Article.Valid != ok || post.Article.String != expected {
+					t.Errorf("expected article of %s to be %q, got %+v", post.Title, expected, post.Article)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerRead(t *testing.T) {
+	server := articleServer(t)
+	feedID := uuid.New()
+	longID, videoID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name            string
+		arguments       []string
+		article         sql.NullString
+		expectedArticle sql.NullString
+		expectError     bool
+	}{
+		{
+			name:            "article downloaded on demand",
+			arguments:       []string{longID.String()},
+			expectedArticle: sql.NullString{String: strings.TrimSpace(articleParagraph), Valid: true},
+		},
+		{
+			name:            "post named by url",
+			arguments:       []string{server.URL + "/posts/long"},
+			expectedArticle: sql.NullString{String: strings.TrimSpace(articleParagraph), Valid: true},
+		},
+		{
+			name:            "stored article",
+			arguments:       []string{longID.String()},
+			article:         sql.NullString{String: "Stored earlier.", Valid: true},
+			expectedArticle: sql.NullString{String: "Stored earlier.", Valid: true},
+		},
+		{
+			name:            "page without article",
+			arguments:       []string{videoID.String()},
+			expectedArticle: sql.NullString{Valid: true},
+		},
+		{
+			name:        "unknown post",
+			arguments:   []string{uuid.New().String()},
+			expectError: true,
+		},
+		{
+			name:        "missing post",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := test.NewMockDb()
+			mockDb.Feeds[server.URL+"/feed.xml"] = database.Rssfeed{ID: feedID, Name: "teasers", Url: server.URL + "/feed.xml"}
+			mockDb.Posts = []database.Post{
+				{ID: longID, FeedID: feedID, Title: "Long read", Url: server.URL + "/posts/long", Article: tt.article},
+				{ID: videoID, FeedID: feedID, Title: "Video", Url: server.URL + "/posts/video"},
+			}
+			state := &State{
+				Db:  mockDb,
+				Cfg: &test.MockCfg{},
+			}
+			cmd := Command{
+				Name:      "read",
+				Arguments: tt.arguments,
+			}
+
+			err := HandlerRead(state, cmd, database.User{ID: uuid.New(), Name: "testuser"})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			for _, post := range mockDb.Posts {
+				if post.ID.String() == tt.arguments[0] || post.Url == tt.arguments[0] {
+					if post.Article != tt.expectedArticle {
+						t.Errorf("expected article %+v, got %+v", tt.expectedArticle, post.Article)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerRead_PageWithoutArticle(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><video src="/clip.mp4"></video></body></html>`))
+	}))
+	defer server.Close()
+
+	postID := uuid.New()
+	mockDb := test.NewMockDb()
+	mockDb.Posts = []database.Post{
+		{ID: postID, FeedID: uuid.New(), Title: "Video", Url: server.URL + "/posts/video", Description: sql.NullString{String: "Watch it.", Valid: true}},
+	}
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	cmd := Command{
+		Name:      "read",
+		Arguments: []string{postID.String()},
+	}
+	user := database.User{ID: uuid.New(), Name: "testuser"}
+
+	for range 2 {
+		if err := HandlerRead(state, cmd, user); err != nil {
+			t.Fatalf("expected no error but got: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the page to be downloaded once, got %d requests", requests)
+	}
+}
+
+func TestHandlerFullArticle(t *testing.T) {
+	tests := []struct {
+		name        string
+		arguments   []string
+		expected    bool
+		expectError bool
+	}{
+		{
+			name:      "on",
+			arguments: []string{"teasers", "on"},
+			expected:  true,
+		},
+		{
+			name:      "off",
+			arguments: []string{"teasers", "off"},
+		},
+		{
+			name:        "invalid mode",
+			arguments:   []string{"teasers", "yes"},
+			expectError: true,
+		},
+		{
+			name:        "unknown feed",
+			arguments:   []string{"other", "on"},
+			expectError: true,
+		},
+		{
+			name:        "feed the user doesn't follow",
+			arguments:   []string{"others-teasers", "off"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := database.User{ID: uuid.New(), Name: "ali"}
+			feed := database.Rssfeed{ID: uuid.New(), Name: "teasers", Url: "https://example.com/feed.xml", FetchFullArticle: true}
+			mockDb := test.NewMockDb()
+			mockDb.Feeds[feed.Url] = feed
+			mockDb.Feeds["https://other.example.com/feed.xml"] = database.Rssfeed{ID: uuid.New(), Name: "others-teasers", Url: "https://other.example.com/feed.xml", FetchFullArticle: true}
+			mockDb.FeedFollows = []database.FeedFollow{{ID: uuid.New(), UserID: user.ID, FeedID: feed.ID}}
+			state := &State{
+				Db:  mockDb,
+				Cfg: &test.MockCfg{},
+			}
+			cmd := Command{
+				Name:      "fullarticle",
+				Arguments: tt.arguments,
+			}
+
+			err := HandlerFullArticle(state, cmd, user)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				if !mockDb.Feeds["https://other.example.com/feed.xml"].FetchFullArticle {
+					t.Errorf("expected feeds the user doesn't follow to be left alone")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if got := mockDb.Feeds["https://example.com/feed.xml"].FetchFullArticle; got != tt.expected {
+				t.Errorf("expected full article mode %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -11,28 +12,69 @@ import (
 	"time"
 
 	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/pubdate"
 	"github.com/ManoloEsS/gator_cli/internal/rss"
+	"github.com/ManoloEsS/gator_cli/internal/sanitize"
+	"github.com/ManoloEsS/gator_cli/internal/websub"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"golang.org/x/net/html"
 )
 
 func HandlerAddFeed(s *State, cmd Command, user database.User) error {
-	if len(cmd.Arguments) < 2 {
-		return fmt.Errorf("usage: %s <name> <url>\n", cmd.Name)
+	flags, args, err := splitFlags(cmd.Arguments, []string{"item", "title", "link", "date", "source", "config"}, "full-article")
+	if err != nil || len(args) < 2 {
+		return fmt.Errorf("usage: %s <name> <url> [--full-article] [--item <selector> --title <selector> [--link <selector>] [--date <selector>]] [--source <type> [--config <json>]]\n", cmd.Name)
 	}
 
-	feedName := cmd.Arguments[0]
-	feedUrl := cmd.Arguments[1]
+	feedName := args[0]
+	feedUrl := args[1]
+	sourceType, sourceConfig, err := sourceFlags(flags)
+	if err != nil {
+		return err
+	}
+
+	// the url can be a stored feed or the old url of a feed that moved
+	stored, found, err := storedFeed(s, feedUrl)
+	if err != nil {
+		return err
+	}
+	if found {
+		return followStoredFeed(s, stored, user)
+	}
+	if sourceType != "" {
+		err = checkSource(s, sourceType, feedUrl, sourceConfig)
+	} else {
+		feedUrl, err = discoverFeedURL(s, cmd.Name, feedUrl)
+	}
+	if err != nil {
+		return err
+	}
+	// the page can advertise a feed that is stored already
+	if feedUrl != args[1] {
+		stored, found, err = storedFeed(s, feedUrl)
+		if err != nil {
+			return err
+		}
+		if found {
+			return followStoredFeed(s, stored, user)
+		}
+	}
 
 	feed, err := s.Db.CreateRSSFeed(context.Background(), database.CreateRSSFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Name:      feedName,
-		Url:       feedUrl,
-		UserID:    user.ID,
+		ID:               uuid.New(),
+		CreatedAt:        time.Now().UTC(),
+		UpdatedAt:        time.Now().UTC(),
+		Name:             feedName,
+		Url:              feedUrl,
+		UserID:           user.ID,
+		FetchFullArticle: flags["full-article"] == "true",
+		SourceType:       sourceType,
+		SourceConfig:     sourceConfig,
 	})
+	if err != nil {
+		return fmt.Errorf("Couldn't add feed to the database: %w", err)
+	}
 
 	_, err = s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
 		ID:        uuid.New(),
@@ -50,24 +92,188 @@ func HandlerAddFeed(s *State, cmd Command, user database.User) error {
 	return nil
 }
 
-func HandlerAgg(s *State, cmd Command) error {
+// storedFeed looks up the feed stored at url or at one of its old urls
+func storedFeed(s *State, url string) (database.Rssfeed, bool, error) {
+	feed, err := s.Db.GetFeedByUrl(context.Background(), url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Rssfeed{}, false, nil
+	}
+	if err != nil {
+		return database.Rssfeed{}, false, fmt.Errorf("Couldn't look up feed %s: %w", url, err)
+	}
+	return feed, true, nil
+}
+
+// followStoredFeed makes the user follow a feed addfeed found stored
+// already, instead of adding it a second time
+func followStoredFeed(s *State, feed database.Rssfeed, user database.User) error {
+	_, err := s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+		fmt.Printf("%s already follows \"%s\" at %s\n", user.Name, feed.Name, feed.Url)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Couldn't create feed follow from stored feed: %w", err)
+	}
+
+	fmt.Printf("\"%s\" is already stored at %s, %s now follows it\n", feed.Name, feed.Url, user.Name)
+	return nil
+}
+
+// Handler that lists the feeds found at a url without adding them
+func HandlerDiscover(s *State, cmd Command) error {
 	if len(cmd.Arguments) < 1 {
-		return fmt.Errorf("usage %s <duration>", cmd.Name)
+		return fmt.Errorf("usage: %s <url>\n", cmd.Name)
 	}
-	time_between_reqs := cmd.Arguments[0]
-	duration, err := time.ParseDuration(time_between_reqs)
+
+	pageUrl := cmd.Arguments[0]
+	feeds, err := httpClient(s).DiscoverFeeds(context.Background(), pageUrl, feedLimits(s))
 	if err != nil {
-		return fmt.Errorf("usage eg: 1s (s: second, m: minute, h: hour): %w", err)
+		return fmt.Errorf("Couldn't discover feeds at %s: %w", pageUrl, err)
+	}
+	if len(feeds) == 0 {
+		fmt.Printf("No feeds found at %s\n", pageUrl)
+		return nil
 	}
-	ticker := time.NewTicker(duration)
-	fmt.Printf("Collecting feeds every %s\n", time_between_reqs)
 
-	for ; ; <-ticker.C {
-		ScrapeFeeds(s)
+	printFeedLinks(pageUrl, feeds)
+	return nil
+}
+
+// discoverFeedURL returns the feed behind the url given to addfeed, which
+// can be the feed itself or an HTML page advertising a single feed
+func discoverFeedURL(s *State, cmdName, pageUrl string) (string, error) {
+	feeds, err := httpClient(s).DiscoverFeeds(context.Background(), pageUrl, feedLimits(s))
+	if err != nil {
+		return "", fmt.Errorf("Couldn't find a feed at %s: %w", pageUrl, err)
+	}
+
+	switch len(feeds) {
+	case 0:
+		return "", fmt.Errorf("no feeds found at %s\n", pageUrl)
+	case 1:
+		if feeds[0].URL != pageUrl {
+			fmt.Printf("Found feed %s\n", feeds[0].URL)
+		}
+		return feeds[0].URL, nil
+	default:
+		printFeedLinks(pageUrl, feeds)
+		return "", fmt.Errorf("%s advertises several feeds, run %s again with one of the urls above\n", pageUrl, cmdName)
+	}
+}
+
+// sourceFlags returns the source type and config given to addfeed. Pages
+// without a feed are scraped with the selectors of their items, other
+// sources are picked with --source and configured with --config
+func sourceFlags(flags map[string]string) (string, json.RawMessage, error) {
+	selectors := rss.Selectors{
+		Item:  flags["item"],
+		Title: flags["title"],
+		Link:  flags["link"],
+		Date:  flags["date"],
 	}
+	sourceType := strings.ToLower(flags["source"])
+	config := json.RawMessage(flags["config"])
+
+	if selectors != (rss.Selectors{}) {
+		if (sourceType != "" && sourceType != "scrape") || len(config) > 0 {
+			return "", nil, errors.New("selectors can't be used with --source or --config")
+		}
+		data, err := json.Marshal(selectors)
+		if err != nil {
+			return "", nil, fmt.Errorf("Couldn't encode selectors: %w", err)
+		}
+		return "scrape", data, nil
+	}
+
+	if len(config) == 0 {
+		if sourceType == "scrape" {
+			return "", nil, errors.New("scrape sources need --item and --title selectors")
+		}
+		return sourceType, json.RawMessage("{}"), nil
+	}
+	if sourceType == "" {
+		return "", nil, errors.New("--config needs a --source type")
+	}
+	if !json.Valid(config) {
+		return "", nil, fmt.Errorf("--config is not valid JSON: %s", config)
+	}
+	return sourceType, config, nil
+}
+
+// checkSource reads the source once, so configs that find no items are
+// reported before the feed is added
+func checkSource(s *State, sourceType, feedUrl string, config json.RawMessage) error {
+	source, err := httpClient(s).NewTypedSource(sourceType, feedUrl, config)
+	if err != nil {
+		return fmt.Errorf("Couldn't use %s source: %w", sourceType, err)
+	}
+	result, err := source.Fetch(context.Background(), rss.CacheValidators{}, feedLimits(s))
+	if err != nil {
+		return fmt.Errorf("Couldn't read %s: %w", feedUrl, err)
+	}
+	items := len(result.Feed.Channel.Item)
+	if items == 0 {
+		return fmt.Errorf("no items found at %s with the %s source\n", feedUrl, sourceType)
+	}
+	fmt.Printf("Found %d items at %s\n", items, feedUrl)
 	return nil
 }
 
+// feedByName returns the only feed with that name
+func feedByName(s *State, feedName string) (database.Rssfeed, error) {
+	feeds, err := s.Db.GetFeedsByName(context.Background(), feedName)
+	if err != nil {
+		return database.Rssfeed{}, fmt.Errorf("Couldn't get feed %s: %w", feedName, err)
+	}
+	if len(feeds) == 0 {
+		return database.Rssfeed{}, fmt.Errorf("no feed named %s, add it first with addfeed\n", feedName)
+	}
+	return onlyFeed(feeds, feedName)
+}
+
+// followedFeedByName returns the feed with that name among the feeds the user follows
+func followedFeedByName(s *State, user database.User, feedName string) (database.Rssfeed, error) {
+	feeds, err := s.Db.GetFollowedFeedsByName(context.Background(), database.GetFollowedFeedsByNameParams{
+		UserID: user.ID,
+		Name:   feedName,
+	})
+	if err != nil {
+		return database.Rssfeed{}, fmt.Errorf("Couldn't get feed %s: %w", feedName, err)
+	}
+	if len(feeds) == 0 {
+		return database.Rssfeed{}, fmt.Errorf("no feed named %s in the feeds %s follows\n", feedName, user.Name)
+	}
+	return onlyFeed(feeds, feedName)
+}
+
+// onlyFeed returns the feed of feeds, which were found by name, unless the
+// name is ambiguous
+func onlyFeed(feeds []database.Rssfeed, feedName string) (database.Rssfeed, error) {
+	if len(feeds) > 1 {
+		return database.Rssfeed{}, fmt.Errorf("%d feeds are named %s, rename one of them\n", len(feeds), feedName)
+	}
+	return feeds[0], nil
+}
+
+func printFeedLinks(pageUrl string, feeds []rss.FeedLink) {
+	fmt.Printf("============ Feeds found at %s ============\n", pageUrl)
+	for _, feed := range feeds {
+		title := feed.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Printf(">%-30s %s\n", title, feed.Type)
+		fmt.Printf("  url:%s\n", feed.URL)
+	}
+}
+
 func HandlerListFeeds(s *State, cmd Command) error {
 	type feeds struct {
 		Name string `json:"name"`
@@ -99,83 +305,362 @@ func HandlerListFeeds(s *State, cmd Command) error {
 }
 
 func HandlerBrowse(s *State, cmd Command, user database.User) error {
+	flags, args, err := splitFlags(cmd.Arguments, []string{"tag"})
+	if err != nil {
+		return fmt.Errorf("usage: %s [limit] [--tag <tag>]: %w\n", cmd.Name, err)
+	}
+
 	var postsNum int32 = 2
-	if len(cmd.Arguments) == 1 {
-		if n, err := strconv.Atoi(cmd.Arguments[0]); err == nil {
+	if len(args) == 1 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
 			postsNum = int32(n)
 		}
 
 	}
-	posts, err := s.Db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  postsNum,
-	})
+
+	posts, err := getPostsForUser(s, user, postsNum, flags["tag"])
 	if err != nil {
 		log.Printf("Couldn't get post for user: %v", err)
 	}
 
 	for _, item := range posts {
-		fmt.Printf("%s from %s\n", item.PublishedAt.Time.Format("Mon Jan 2"), item.FeedName)
+		published := item.PublishedAt.Time.Format("Mon Jan 2")
+		if item.PublishedAtGuessed {
+			published = "~" + published
+		}
+		fmt.Printf("%s from %s\n", published, item.FeedName)
 		fmt.Printf("---%s---\n", item.Title)
-		fmt.Printf("   %v\n", StripHTML(item.Description.String))
-		fmt.Printf("Link: %s\n", item.Url)
+		if item.Author.Valid {
+			fmt.Printf("By %s\n", item.Author.String)
+		}
+		fmt.Printf("   %v\n", StripHTML(postBody(item)))
+		if item.Url != "" {
+			fmt.Printf("Link: %s\n", item.Url)
+		}
+		fmt.Printf("Id: %s\n", item.ID)
+
+		tags, err := s.Db.GetTagsForPost(context.Background(), item.ID)
+		if err != nil {
+			log.Printf("Couldn't get tags for post %s: %v", item.Title, err)
+		}
+		if len(tags) > 0 {
+			fmt.Printf("Tags: %s\n", strings.Join(tags, ", "))
+		}
+
+		enclosures, err := s.Db.GetEnclosuresForPost(context.Background(), item.ID)
+		if err != nil {
+			log.Printf("Couldn't get enclosures for post %s: %v", item.Title, err)
+		}
+		for _, enclosure := range enclosures {
+			fmt.Printf("Media: %s %s\n", enclosure.Url, describeEnclosure(enclosure))
+		}
 		fmt.Println("====================================")
 	}
 	return nil
 }
 
-func scrapeFeed(db DBInterface, feed database.Rssfeed) {
-	err := db.MarkFeedFetched(context.Background(), feed.ID)
+// postBody returns the full content of the post when the feed provided it,
+// falling back to the description, which many feeds use for a teaser
+func postBody(post database.GetPostsForUserRow) string {
+	if post.Content.Valid && strings.TrimSpace(post.Content.String) != "" {
+		return post.Content.String
+	}
+	return post.Description.String
+}
+
+// getPostsForUser returns the latest posts of the feeds the user follows,
+// only the ones tagged with tag when it is not empty
+func getPostsForUser(s *State, user database.User, limit int32, tag string) ([]database.GetPostsForUserRow, error) {
+	if tag == "" {
+		return s.Db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
+			UserID: user.ID,
+			Limit:  limit,
+		})
+	}
+
+	tagged, err := s.Db.GetPostsForUserByTag(context.Background(), database.GetPostsForUserByTagParams{
+		UserID: user.ID,
+		Name:   normalizeTag(tag),
+		Limit:  limit,
+	})
 	if err != nil {
-		log.Printf("couldn't mark feed %s fetched: %v", feed.Name, err)
-		return
+		return nil, err
+	}
+
+	posts := make([]database.GetPostsForUserRow, 0, len(tagged))
+	for _, post := range tagged {
+		posts = append(posts, database.GetPostsForUserRow(post))
+	}
+	return posts, nil
+}
+
+// scrapeFeed fetches the feed and logs how it went
+func scrapeFeed(s *State, feed database.Rssfeed) {
+	fetchResult, err := fetchFeed(s, feed)
+	logFetch(feed, fetchResult, err)
+}
+
+// fetchFeed reads the feed from its source and stores its new posts
+func fetchFeed(s *State, feed database.Rssfeed) (*rss.FetchResult, error) {
+	err := s.Db.MarkFeedFetched(context.Background(), feed.ID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't mark feed fetched: %w", err)
 	}
 
-	rssResponseData, err := rss.FetchFeed(context.Background(), feed.Url)
+	source, err := feedSource(s, feed)
 	if err != nil {
-		log.Printf("couldn't fetch from feed %s: %w", feed.Url, err)
-		return
+		return nil, err
 	}
+	fetchResult, err := ingestFeed(s, feed, source)
+	if err != nil {
+		return nil, err
+	}
+	scheduleNextFetch(s.Db, feed, fetchResult)
+	if fetchResult.Feed != nil {
+		updateFeedHub(s, feed, fetchResult)
+	}
+	return fetchResult, nil
+}
+
+// logFetch reports the outcome of reading the feed
+func logFetch(feed database.Rssfeed, fetchResult *rss.FetchResult, err error) {
+	switch {
+	case err != nil:
+		log.Printf("couldn't fetch from feed %s: %v", feed.Url, err)
+	case fetchResult.NotModified:
+		log.Printf("Feed %s not modified since last fetch", feed.Name)
+	default:
+		fmt.Println("===============================================")
+		log.Printf("Feed %s collected, %v posts found", feed.Name, len(fetchResult.Feed.Channel.Item))
+	}
+}
 
+// feedSource returns where the feed is read from, built by the adapter of
+// its source type or of its url scheme
+func feedSource(s *State, feed database.Rssfeed) (rss.Source, error) {
+	return httpClient(s).NewTypedSource(feed.SourceType, feed.Url, feed.SourceConfig)
+}
+
+// ingestFeed reads the feed from source and stores the posts that are new,
+// callers reading the whole feed schedule its next fetch. Only reading the
+// feed fails, problems storing single posts are logged
+func ingestFeed(s *State, feed database.Rssfeed, source rss.Source) (*rss.FetchResult, error) {
+	db := s.Db
+	fetchResult, err := source.Fetch(context.Background(), rss.CacheValidators{
+		ETag:         feed.Etag.String,
+		LastModified: feed.LastModified.String,
+	}, feedLimits(s))
+	if err != nil {
+		return nil, err
+	}
+
+	if fetchResult.MovedTo != "" && fetchResult.MovedTo != feed.Url {
+		err = db.MoveFeed(context.Background(), database.MoveFeedParams{
+			ID:  feed.ID,
+			Url: fetchResult.MovedTo,
+		})
+		if err != nil {
+			log.Printf("couldn't update url of moved feed %s: %v", feed.Name, err)
+		} else {
+			log.Printf("Feed %s moved permanently to %s", feed.Name, fetchResult.MovedTo)
+			feed.Url = fetchResult.MovedTo
+		}
+	}
+
+	if fetchResult.NotModified {
+		return fetchResult, nil
+	}
+
+	err = db.UpdateFeedCache(context.Background(), database.UpdateFeedCacheParams{
+		ID:           feed.ID,
+		Etag:         newNullString(fetchResult.Validators.ETag),
+		LastModified: newNullString(fetchResult.Validators.LastModified),
+	})
+	if err != nil {
+		log.Printf("couldn't store cache validators for feed %s: %v", feed.Name, err)
+	}
+
+	rssResponseData := fetchResult.Feed
+
+	fetchedAt := time.Now().UTC()
 	for _, item := range rssResponseData.Channel.Item {
-		publishedDateParsed := sql.NullTime{}
-		if t, ok := parsePubDate(item.PubDate); ok {
-			publishedDateParsed = newNullTime(t)
+		publishedAt, err := publishedDate(item, fetchedAt)
+		if err != nil {
+			log.Printf("couldn't parse date of post %s: %v", item.Title, err)
 		}
 
-		_, err := db.CreatePost(context.Background(), database.CreatePostParams{
+		post, err := db.CreatePost(context.Background(), database.CreatePostParams{
 			ID:        uuid.New(),
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
-			Title:     item.Title,
+			Title:     sanitize.Text(item.Title),
 			Url:       item.Link,
 			Description: sql.NullString{
-				String: item.Description,
+				String: sanitize.HTML(item.Description),
 				Valid:  true,
 			},
-			PublishedAt: publishedDateParsed,
-			FeedID:      feed.ID,
+			PublishedAt:        newNullTime(publishedAt.Time),
+			FeedID:             feed.ID,
+			Guid:               item.Identity(),
+			Author:             newNullString(sanitize.Text(item.Author)),
+			Content:            newNullString(sanitize.HTML(item.Content)),
+			PublishedAtGuessed: publishedAt.Guessed,
 		})
 		if err != nil {
+			// the post is already stored for this feed (feed_id, guid)
 			if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
 				continue
 			}
 			log.Printf("couldn't add post to database: %v", err)
 			continue
 		}
+		if adoptLegacyPost(db, post) {
+			continue
+		}
+
+		storeEnclosures(db, post.ID, item)
+		storeTags(db, post.ID, item.Categories)
+		if feed.FetchFullArticle && post.Url != "" {
+			if _, err := fetchArticle(s, post.ID, post.Url); err != nil {
+				log.Printf("couldn't get full article of post %s: %v", post.Title, err)
+			}
+		}
 	}
+	return fetchResult, nil
+}
 
-	fmt.Println("===============================================")
-	log.Printf("Feed %s collected, %v posts found", feed.Name, len(rssResponseData.Channel.Item))
+// adoptLegacyPost gives the guid of a post that was just created to the post
+// stored with its link before guids were tracked, if there is one, and drops
+// the new post. Only new posts are checked, the items already stored are
+// found by their guid
+func adoptLegacyPost(db DBInterface, post database.Post) bool {
+	if post.Url == "" {
+		return false
+	}
+	deleted, err := db.DeleteLegacyDuplicate(context.Background(), post.ID)
+	if err != nil {
+		log.Printf("couldn't match post %s with stored posts: %v", post.Title, err)
+		return false
+	}
+	if deleted == 0 {
+		return false
+	}
+	_, err = db.AdoptLegacyPost(context.Background(), database.AdoptLegacyPostParams{
+		FeedID: post.FeedID,
+		Url:    post.Url,
+		Guid:   post.Guid,
+	})
+	if err != nil {
+		log.Printf("couldn't give guid of post %s to its stored post: %v", post.Title, err)
+	}
+	return true
 }
-func ScrapeFeeds(s *State) {
-	nextFeed, err := s.Db.GetNextFeedToFetch(context.Background())
+
+// updateFeedHub keeps track of the WebSub hub the feed advertises, the feed
+// is subscribed to by the websub command. A subscription to a hub the feed
+// stopped advertising is cancelled
+func updateFeedHub(s *State, feed database.Rssfeed, fetchResult *rss.FetchResult) {
+	db := s.Db
+	channel := fetchResult.Feed.Channel
+	if channel.Hub == "" {
+		unsubscribeFeedHub(s, feed, "", "")
+		if err := db.DeleteFeedHub(context.Background(), feed.ID); err != nil {
+			log.Printf("couldn't remove hub of feed %s: %v", feed.Name, err)
+		}
+		return
+	}
+
+	// the topic is the url the publisher pings the hub with, the feed url
+	// only when the feed doesn't tell
+	topic := channel.Self
+	if topic == "" {
+		topic = feed.Url
+		if fetchResult.MovedTo != "" {
+			topic = fetchResult.MovedTo
+		}
+	}
+	unsubscribeFeedHub(s, feed, channel.Hub, topic)
+	err := db.SetFeedHub(context.Background(), database.SetFeedHubParams{
+		FeedID:    feed.ID,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Hub:       channel.Hub,
+		Topic:     topic,
+	})
+	if err != nil {
+		log.Printf("couldn't store hub of feed %s: %v", feed.Name, err)
+	}
+}
+
+// unsubscribeFeedHub asks the hub the feed was subscribed to to stop pushing
+// its updates, unless it is still the hub and topic the feed advertises.
+// The hub keeps pushing until the lease ends when it can't be reached
+func unsubscribeFeedHub(s *State, feed database.Rssfeed, hub, topic string) {
+	sub, err := s.Db.GetSubscription(context.Background(), feed.ID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("couldn't get subscription of feed %s: %v", feed.Name, err)
+		}
+		return
+	}
+	if (sub.Hub == hub && sub.Topic == topic) || !sub.RequestedAt.Valid || sub.Callback == "" {
+		return
+	}
+
+	err = websub.Unsubscribe(context.Background(), httpClient(s).HTTPClient(), websub.Request{
+		Hub:      sub.Hub,
+		Topic:    sub.Topic,
+		Callback: sub.Callback,
+	})
 	if err != nil {
-		log.Println("couldn't get next feed to fetch", err)
+		log.Printf("couldn't unsubscribe from %s at %s: %v", sub.Topic, sub.Hub, err)
 		return
 	}
-	log.Println("Found a feed to fetch!")
-	scrapeFeed(s.Db, nextFeed)
+	log.Printf("Unsubscription from %s requested at %s", sub.Topic, sub.Hub)
+}
+
+// scheduleNextFetch schedules the feed after it was read, with the hints of
+// the feed or, when it wasn't modified, the delay it was scheduled with before
+func scheduleNextFetch(db DBInterface, feed database.Rssfeed, fetchResult *rss.FetchResult) {
+	now := time.Now().UTC()
+	if fetchResult.NotModified {
+		scheduleFeed(db, feed, now.Add(previousFetchDelay(feed)))
+		return
+	}
+	scheduleFeed(db, feed, fetchResult.Feed.Schedule().NextFetch(now))
+}
+
+// scheduleFeed stores when the feed is due to be fetched again
+func scheduleFeed(db DBInterface, feed database.Rssfeed, next time.Time) {
+	err := db.SetFeedNextFetch(context.Background(), database.SetFeedNextFetchParams{
+		ID:          feed.ID,
+		NextFetchAt: newNullTime(next),
+	})
+	if err != nil {
+		log.Printf("couldn't schedule next fetch of feed %s: %v", feed.Name, err)
+	}
+}
+
+// unknownFetchDelay is the delay of a not modified feed that was never
+// scheduled, new feeds and feeds stored before fetches were scheduled
+const unknownFetchDelay = time.Hour
+
+// previousFetchDelay is the delay the feed was scheduled with on its last
+// fetch, a not modified response carries no schedule so the same one is kept
+func previousFetchDelay(feed database.Rssfeed) time.Duration {
+	if !feed.NextFetchAt.Valid || !feed.LastFetchedAt.Valid {
+		return unknownFetchDelay
+	}
+	return max(feed.NextFetchAt.Time.Sub(feed.LastFetchedAt.Time), 0)
+}
+
+// feedLimits converts the limits from the config file into rss limits
+func feedLimits(s *State) rss.Limits {
+	limits := s.Cfg.GetFeedLimits()
+	return rss.Limits{
+		MaxBodySize: limits.MaxBodySize,
+		MaxItems:    limits.MaxItems,
+	}
 }
 
 func newNullTime(t time.Time) sql.NullTime {
@@ -188,24 +673,28 @@ func newNullTime(t time.Time) sql.NullTime {
 	}
 }
 
-var rssLayouts = []string{
-	time.RFC1123,                      // "Mon, 02 Jan 2006 15:04:05 MST"
-	time.RFC1123Z,                     // "Mon, 02 Jan 2006 15:04:05 -0700"
-	time.RFC822,                       // "02 Jan 06 15:04 MST"
-	time.RFC822Z,                      // "02 Jan 06 15:04 -0700"
-	time.RFC3339,                      // "2006-01-02T15:04:05Z07:00"
-	"Mon, 02 Jan 2006 15:04 MST",      // no seconds
-	"Mon, 02 Jan 2006 15:04:05 -0700", // explicit offset
+func newNullString(s string) sql.NullString {
+	if s == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{
+		String: s,
+		Valid:  true,
+	}
 }
 
-func parsePubDate(s string) (time.Time, bool) {
-	s = strings.TrimSpace(s)
-	for _, layout := range rssLayouts {
-		if t, err := time.Parse(layout, s); err == nil {
-			return t, true
-		}
+// publishedDate parses the publication date of the item. Items without a
+// date that can be read are dated when they were fetched, as a guess, the
+// error is only returned for dates that are present but unreadable
+func publishedDate(item rss.RSSItem, fetchedAt time.Time) (pubdate.Date, error) {
+	if strings.TrimSpace(item.PubDate) == "" {
+		return pubdate.Date{Time: fetchedAt, Guessed: true}, nil
+	}
+	date, err := pubdate.Parse(item.PubDate)
+	if err != nil {
+		return pubdate.Date{Time: fetchedAt, Guessed: true}, err
 	}
-	return time.Time{}, false
+	return date, nil
 }
 
 func StripHTML(s string) string {
@@ -0,0 +1,730 @@
+package cli
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/config"
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/test"
+	"github.com/google/uuid"
+)
+
+const testFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Gator Blog</title></channel></rss>`
+
+func TestHandlerAddFeed(t *testing.T) {
+	tests := []struct {
+		name                string
+		page                string
+		flags               []string
+		expectError         bool
+		errorMsg            string
+		limits              config.FeedLimits
+		expectedUrl         string
+		expectedFullArticle bool
+	}{
+		{
+			name:        "url is a feed",
+			page:        "/feed.xml",
+			expectedUrl: "/feed.xml",
+		},
+		{
+			name:        "page advertising one feed",
+			page:        "/single",
+			expectedUrl: "/feed.xml",
+		},
+		{
+			name:                "full article mode",
+			page:                "/feed.xml",
+			flags:               []string{"--full-article"},
+			expectedUrl:         "/feed.xml",
+			expectedFullArticle: true,
+		},
+		{
+			name:        "page advertising several feeds",
+			page:        "/multiple",
+			expectError: true,
+			errorMsg:    "advertises several feeds",
+		},
+		{
+			name:        "page without feeds",
+			page:        "/none",
+			expectError: true,
+			errorMsg:    "no feeds found",
+		},
+		{
+			name:        "page larger than the configured limit",
+			page:        "/large",
+			limits:      config.FeedLimits{MaxBodySize: 1024},
+			expectError: true,
+			errorMsg:    "max body size exceeded",
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed.xml", "/atom.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte(testFeed))
+		case "/single":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" href="/feed.xml"></head></html>`))
+		case "/multiple":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head>
+<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+<link rel="alternate" type="application/atom+xml" href="/atom.xml">
+</head></html>`))
+		case "/large":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>` + strings.Repeat("gator ", 1000) + `</title>
+<link rel="alternate" type="application/rss+xml" href="/feed.xml"></head></html>`))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>No feeds here</title></head></html>`))
+		}
+	}))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := test.NewMockDb()
+			state := &State{
+				Db:  mockDb,
+				Cfg: &test.MockCfg{FeedLimits: tt.limits},
+			}
+			cmd := Command{
+				Name:      "addfeed",
+				Arguments: append([]string{"gator blog", server.URL + tt.page}, tt.flags...),
+			}
+
+			err := HandlerAddFeed(state, cmd, database.User{ID: uuid.New(), Name: "testuser"})
+
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error to contain %q, got %q", tt.errorMsg, err.Error())
+				}
+				if len(mockDb.Feeds) != 0 {
+					t.Errorf("expected no feed to be added, got %d", len(mockDb.Feeds))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			feed, exists := mockDb.Feeds[server.URL+tt.expectedUrl]
+			if !exists {
+				t.Fatalf("expected feed %s to be added, got %v", server.URL+tt.expectedUrl, mockDb.Feeds)
+			}
+			if feed.FetchFullArticle != tt.expectedFullArticle {
+				t.Errorf("expected full article mode %v, got %v", tt.expectedFullArticle, feed.FetchFullArticle)
+			}
+		})
+	}
+}
+
+func TestHandlerAddFeed_StoredFeed(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" href="/moved.xml"></head></html>`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		url              string
+		expectedRequests int
+	}{
+		{
+			name: "stored url",
+			url:  server.URL + "/moved.xml",
+		},
+		{
+			name: "old url of a moved feed",
+			url:  server.URL + "/feed.xml",
+		},
+		{
+			name:             "page advertising a stored feed",
+			url:              server.URL + "/blog",
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			mockDb := test.NewMockDb()
+			state := &State{
+				Db:  mockDb,
+				Cfg: &test.MockCfg{},
+			}
+			feed := database.Rssfeed{ID: uuid.New(), Name: "gator blog", Url: server.URL + "/moved.xml"}
+			mockDb.Feeds[feed.Url] = feed
+			mockDb.FeedAliases[server.URL+"/feed.xml"] = feed.ID
+			user := database.User{ID: uuid.New(), Name: "testuser"}
+
+			err := HandlerAddFeed(state, Command{Name: "addfeed", Arguments: []string{"gator", tt.url}}, user)
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if len(mockDb.Feeds) != 1 {
+				t.Errorf("expected no second feed, got %v", mockDb.Feeds)
+			}
+			if len(mockDb.FeedFollows) != 1 || mockDb.FeedFollows[0].FeedID != feed.ID || mockDb.FeedFollows[0].UserID != user.ID {
+				t.Errorf("expected the user to follow the stored feed, got %+v", mockDb.FeedFollows)
+			}
+			if requests != tt.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tt.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestScrapeFeed_ConditionalGet(t *testing.T) {
+	const etag = `"v1"`
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`<rss><channel><title>Gator Blog</title>
+<item><title>First</title><link>https://example.com/first</link></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	mockDb.Feeds[server.URL] = database.Rssfeed{ID: uuid.New(), Name: "gator blog", Url: server.URL}
+
+	scrapeFeed(state, mockDb.Feeds[server.URL])
+
+	if len(mockDb.Posts) != 1 {
+		t.Fatalf("expected 1 post after first fetch, got %d", len(mockDb.Posts))
+	}
+	feed := mockDb.Feeds[server.URL]
+	if feed.Etag.String != etag {
+		t.Fatalf("expected etag %q to be stored, got %q", etag, feed.Etag.String)
+	}
+
+	scrapeFeed(state, feed)
+
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if len(mockDb.Posts) != 1 {
+		t.Errorf("expected no new posts on a 304 response, got %d posts", len(mockDb.Posts))
+	}
+}
+
+func TestScrapeFeed_PostIdentity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><title>Gator Blog</title>
+<item><title>Shared article</title><link>https://example.com/shared</link></item>
+<item><title>Note without link</title><guid isPermaLink="false">note-1</guid></item>
+<item><title>Same link, other guid</title><link>https://example.com/shared</link><guid>shared-2</guid></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	firstFeed := database.Rssfeed{ID: uuid.New(), Name: "first", Url: server.URL}
+	secondFeed := database.Rssfeed{ID: uuid.New(), Name: "second", Url: server.URL + "/other"}
+
+	scrapeFeed(state, firstFeed)
+	if len(mockDb.Posts) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(mockDb.Posts))
+	}
+
+	scrapeFeed(state, firstFeed)
+	if len(mockDb.Posts) != 3 {
+		t.Errorf("expected scraping the same feed again to store no duplicates, got %d posts", len(mockDb.Posts))
+	}
+
+	scrapeFeed(state, secondFeed)
+	if len(mockDb.Posts) != 6 {
+		t.Errorf("expected another feed linking the same articles to get its own posts, got %d posts", len(mockDb.Posts))
+	}
+
+	expectedGuids := []string{"https://example.com/shared", "note-1", "shared-2"}
+	for i, expected := range expectedGuids {
+		if mockDb.Posts[i].Guid != expected {
+			t.Errorf("post %d: expected guid %q, got %q", i, expected, mockDb.Posts[i].Guid)
+		}
+	}
+}
+
+func TestScrapeFeed_LegacyPosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><title>Gator Blog</title>
+<item><title>Old post</title><link>https://example.com/old</link><guid isPermaLink="false">post-1</guid></item>
+<item><title>New post</title><link>https://example.com/new</link><guid isPermaLink="false">post-2</guid></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	feed := database.Rssfeed{ID: uuid.New(), Name: "gator", Url: server.URL}
+	// stored before guids were tracked, keyed by its link
+	mockDb.Posts = append(mockDb.Posts, database.Post{
+		ID:         uuid.New(),
+		Title:      "Old post",
+		Url:        "https://example.com/old",
+		FeedID:     feed.ID,
+		Guid:       "https://example.com/old",
+		LegacyGuid: true,
+	})
+
+	scrapeFeed(state, feed)
+	scrapeFeed(state, feed)
+
+	if len(mockDb.Posts) != 2 {
+		t.Fatalf("expected the stored post to be matched by its link, got %d posts", len(mockDb.Posts))
+	}
+	legacy := mockDb.Posts[0]
+	if legacy.Guid != "post-1" || legacy.LegacyGuid {
+		t.Errorf("expected the stored post to take the guid of its item, got %q legacy %v", legacy.Guid, legacy.LegacyGuid)
+	}
+}
+
+func TestScrapeFeed_AuthorAndContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel><title>Gator Blog</title>
+<item><title>Full post</title><link>https://example.com/full</link>
+<description>Teaser</description>
+<content:encoded><![CDATA[<p>The whole article</p>]]></content:encoded>
+<author>ali@example.com (Ali Gator)</author></item>
+<item><title>Teaser only</title><link>https://example.com/teaser</link>
+<description>Just a teaser</description><dc:creator>Croc</dc:creator></item>
+<item><title>Anonymous</title><link>https://example.com/anonymous</link></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	scrapeFeed(state, database.Rssfeed{ID: uuid.New(), Name: "gator", Url: server.URL})
+
+	tests := []struct {
+		name            string
+		expectedAuthor  sql.NullString
+		expectedContent sql.NullString
+		expectedBody    string
+	}{
+		{
+			name:            "content:encoded and RSS author",
+			expectedAuthor:  sql.NullString{String: "Ali Gator", Valid: true},
+			expectedContent: sql.NullString{String: "<p>The whole article</p>", Valid: true},
+			expectedBody:    "<p>The whole article</p>",
+		},
+		{
+			name:           "dc:creator without content",
+			expectedAuthor: sql.NullString{String: "Croc", Valid: true},
+			expectedBody:   "Just a teaser",
+		},
+		{
+			name: "no author or content",
+		},
+	}
+
+	if len(mockDb.Posts) != len(tests) {
+		t.Fatalf("expected %d posts, got %d", len(tests), len(mockDb.Posts))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := mockDb.Posts[i]
+			if post.Author != tt.expectedAuthor {
+				t.Errorf("expected author %+v, got %+v", tt.expectedAuthor, post.Author)
+			}
+			if post.Content != tt.expectedContent {
+				t.Errorf("expected content %+v, got %+v", tt.expectedContent, post.Content)
+			}
+
+			body := postBody(database.GetPostsForUserRow{Description: post.Description, Content: post.Content})
+			if body != tt.expectedBody {
+				t.Errorf("expected browse to show %q, got %q", tt.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestScrapeFeed_SanitizesHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel><title>Gator Blog</title>
+<item><title>&lt;b&gt;Unsafe&lt;/b&gt; post&lt;script&gt;steal()&lt;/script&gt;</title><link>https://example.com/unsafe</link>
+<author>&lt;a href="javascript:steal()"&gt;Ali Gator&lt;/a&gt;</author>
+<description><![CDATA[<p onmouseover="steal()">Teaser</p><script>steal()</script>]]></description>
+<content:encoded><![CDATA[<p>Article <a href="javascript:steal()">link</a></p><iframe src="https://ads.example.com/"></iframe><img src="https://t.example.com/pixel.gif" width="1" height="1">]]></content:encoded>
+</item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	scrapeFeed(state, database.Rssfeed{ID: uuid.New(), Name: "gator", Url: server.URL})
+
+	if len(mockDb.Posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(mockDb.Posts))
+	}
+	post := mockDb.Posts[0]
+	if post.Title != "Unsafe post" {
+		t.Errorf("expected title without markup, got %q", post.Title)
+	}
+	if post.Author.String != "Ali Gator" {
+		t.Errorf("expected author without markup, got %q", post.Author.String)
+	}
+	if post.Description.String != "<p>Teaser</p>" {
+		t.Errorf("expected sanitized description, got %q", post.Description.String)
+	}
+	if post.Content.String != "<p>Article <a>link</a></p>" {
+		t.Errorf("expected sanitized content, got %q", post.Content.String)
+	}
+}
+
+func TestScrapeFeed_Redirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/mirror":
+			http.Redirect(w, r, "/new", http.StatusFound)
+		default:
+			w.Write([]byte(testFeed))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		expectedUrl string
+	}{
+		{
+			name:        "permanent redirect moves the feed",
+			path:        "/old",
+			expectedUrl: server.URL + "/new",
+		},
+		{
+			name:        "temporary redirect keeps the url",
+			path:        "/mirror",
+			expectedUrl: server.URL + "/mirror",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := test.NewMockDb()
+			state := &State{
+				Db:  mockDb,
+				Cfg: &test.MockCfg{},
+			}
+			user := database.User{ID: uuid.New(), Name: "gator"}
+			feedUrl := server.URL + tt.path
+			mockDb.Feeds[feedUrl] = database.Rssfeed{ID: uuid.New(), Name: "gator blog", Url: feedUrl}
+
+			scrapeFeed(state, mockDb.Feeds[feedUrl])
+
+			feed, exists := mockDb.Feeds[tt.expectedUrl]
+			if !exists || feed.Url != tt.expectedUrl {
+				t.Fatalf("expected the feed to be stored at %s, feeds: %+v", tt.expectedUrl, mockDb.Feeds)
+			}
+			for _, cmdName := range []string{"follow", "unfollow"} {
+				cmd := Command{Name: cmdName, Arguments: []string{feedUrl}}
+				handler := HandlerFeedFollow
+				if cmdName == "unfollow" {
+					handler = HandlerUnfollowFeed
+				}
+				if err := handler(state, cmd, user); err != nil {
+					t.Errorf("expected %s with the original url to resolve, got %v", cmdName, err)
+				}
+			}
+		})
+	}
+}
+
+func TestScrapeFeed_PublishedDates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><title>Gator Blog</title>
+<item><title>Named zone</title><link>https://example.com/1</link><pubDate>Mon, 6 May 2024 10:00:00 EDT</pubDate></item>
+<item><title>Date only</title><link>https://example.com/2</link><pubDate>2024-05-07</pubDate></item>
+<item><title>Unreadable</title><link>https://example.com/3</link><pubDate>last tuesday</pubDate></item>
+<item><title>Missing</title><link>https://example.com/4</link></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	before := time.Now().UTC()
+	scrapeFeed(state, database.Rssfeed{ID: uuid.New(), Name: "gator", Url: server.URL})
+	after := time.Now().UTC()
+
+	tests := []struct {
+		name            string
+		expected        time.Time
+		expectedGuessed bool
+		expectFetchTime bool
+	}{
+		{
+			name:     "named zone",
+			expected: time.Date(2024, 5, 6, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "date only",
+			expected:        time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC),
+			expectedGuessed: true,
+		},
+		{
+			name:            "unreadable date",
+			expectedGuessed: true,
+			expectFetchTime: true,
+		},
+		{
+			name:            "missing date",
+			expectedGuessed: true,
+			expectFetchTime: true,
+		},
+	}
+
+	if len(mockDb.Posts) != len(tests) {
+		t.Fatalf("expected %d posts, got %d", len(tests), len(mockDb.Posts))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := mockDb.Posts[i]
+			if !post.PublishedAt.Valid {
+				t.Fatalf("expected a published date")
+			}
+			if tt.expectFetchTime {
+				if post.PublishedAt.Time.Before(before) || post.PublishedAt.Time.After(after) {
+					t.Errorf("expected the fetch time, got %v", post.PublishedAt.Time)
+				}
+			} else if !post.PublishedAt.Time.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, post.PublishedAt.Time)
+			}
+			if post.PublishedAtGuessed != tt.expectedGuessed {
+				t.Errorf("expected guessed = %v, got %v", tt.expectedGuessed, post.PublishedAtGuessed)
+			}
+		})
+	}
+}
+
+func TestHandlerAddFeed_Scraped(t *testing.T) {
+	page := `<html><body><div class="news">
+<div class="story"><h2>First story</h2><a href="/stories/1">more</a><time datetime="2025-01-06T10:00:00Z">Monday</time></div>
+<div class="story"><h2>Second story</h2><a href="/stories/2">more</a></div>
+</div></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		flags       []string
+		expectError bool
+	}{
+		{
+			name:  "selectors",
+			flags: []string{"--item", ".story", "--title", "h2", "--date", "time"},
+		},
+		{
+			name:        "missing title selector",
+			flags:       []string{"--item", ".story"},
+			expectError: true,
+		},
+		{
+			name:        "invalid selector",
+			flags:       []string{"--item", ".story +", "--title", "h2"},
+			expectError: true,
+		},
+		{
+			name:        "no matching items",
+			flags:       []string{"--item", "article", "--title", "h2"},
+			expectError: true,
+		},
+		{
+			name:        "selectors with another source",
+			flags:       []string{"--item", ".story", "--title", "h2", "--source", "json"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := test.NewMockDb()
+			state := &State{
+				Db:  mockDb,
+				Cfg: &test.MockCfg{},
+			}
+			cmd := Command{
+				Name:      "addfeed",
+				Arguments: append([]string{"news", server.URL + "/news"}, tt.flags...),
+			}
+
+			err := HandlerAddFeed(state, cmd, database.User{ID: uuid.New(), Name: "testuser"})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				if len(mockDb.Feeds) != 0 {
+					t.Errorf("expected no feed to be added, got %d", len(mockDb.Feeds))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			feed, exists := mockDb.Feeds[server.URL+"/news"]
+			if !exists {
+				t.Fatalf("expected the page to be added as a feed, got %v", mockDb.Feeds)
+			}
+			expectedConfig := `{"item":".story","title":"h2","date":"time"}`
+			if feed.SourceType != "scrape" || string(feed.SourceConfig) != expectedConfig {
+				t.Errorf("expected the selectors to be stored, got %q %s", feed.SourceType, feed.SourceConfig)
+			}
+
+			scrapeFeed(state, feed)
+			if len(mockDb.Posts) != 2 {
+				t.Fatalf("expected 2 posts, got %d", len(mockDb.Posts))
+			}
+			first := mockDb.Posts[0]
+			if first.Title != "First story" || first.Url != server.URL+"/stories/1" {
+				t.Errorf("expected the first story, got %q %q", first.Title, first.Url)
+			}
+			if first.PublishedAtGuessed || !first.PublishedAt.Time.Equal(time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)) {
+				t.Errorf("expected the date of the story, got %v", first.PublishedAt)
+			}
+			if !mockDb.Posts[1].PublishedAtGuessed {
+				t.Errorf("expected the story without date to be dated when fetched")
+			}
+		})
+	}
+}
+
+func TestHandlerAddFeed_Source(t *testing.T) {
+	api := `{"hits": [
+  {"objectID": "1", "title": "Show HN: gator", "url": "https://example.com/gator", "created_at_i": 1736157600},
+  {"objectID": "2", "title": "Ask HN: feeds?"}
+]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(api))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		flags       []string
+		expectError bool
+	}{
+		{
+			name:  "json mapping",
+			flags: []string{"--source", "json", "--config", `{"items":"hits","title":"title","link":"url","id":"objectID","date":"created_at_i"}`},
+		},
+		{
+			name:        "unknown source type",
+			flags:       []string{"--source", "gopher", "--config", `{}`},
+			expectError: true,
+		},
+		{
+			name:        "unknown config field",
+			flags:       []string{"--source", "json", "--config", `{"items":"hits","title":"title","body":"text"}`},
+			expectError: true,
+		},
+		{
+			name:        "invalid config",
+			flags:       []string{"--source", "json", "--config", `{"items":`},
+			expectError: true,
+		},
+		{
+			name:        "config without source",
+			flags:       []string{"--config", `{"title":"title"}`},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := test.NewMockDb()
+			state := &State{
+				Db:  mockDb,
+				Cfg: &test.MockCfg{},
+			}
+			cmd := Command{
+				Name:      "addfeed",
+				Arguments: append([]string{"hn", server.URL + "/search"}, tt.flags...),
+			}
+
+			err := HandlerAddFeed(state, cmd, database.User{ID: uuid.New(), Name: "testuser"})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				if len(mockDb.Feeds) != 0 {
+					t.Errorf("expected no feed to be added, got %d", len(mockDb.Feeds))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			feed, exists := mockDb.Feeds[server.URL+"/search"]
+			if !exists || feed.SourceType != "json" {
+				t.Fatalf("expected the api to be added as a json source, got %v", mockDb.Feeds)
+			}
+
+			scrapeFeed(state, feed)
+			if len(mockDb.Posts) != 2 {
+				t.Fatalf("expected 2 posts, got %d", len(mockDb.Posts))
+			}
+			first := mockDb.Posts[0]
+			if first.Title != "Show HN: gator" || first.Guid != "1" || first.Url != "https://example.com/gator" {
+				t.Errorf("expected the first hit, got %q %q %q", first.Title, first.Guid, first.Url)
+			}
+			if !first.PublishedAt.Time.Equal(time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)) {
+				t.Errorf("expected the date of the hit, got %v", first.PublishedAt)
+			}
+		})
+	}
+}
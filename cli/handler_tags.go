@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/google/uuid"
+)
+
+// Handler that lists the tags of the posts in the feeds the user follows,
+// with the number of posts for each tag
+func HandlerListTags(s *State, cmd Command, user database.User) error {
+	tags, err := s.Db.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("Couldn't get tags for user %s: %w", user.Name, err)
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags found in the feeds this user follows.")
+		return nil
+	}
+
+	fmt.Printf("============ User %s's tags ============\n", user.Name)
+	fmt.Printf("Tag:                          Posts:\n")
+	for _, tag := range tags {
+		fmt.Printf("%-30s%d\n", tag.Name, tag.PostCount)
+	}
+	return nil
+}
+
+// storeTags links the categories of a feed item to the post as tags
+func storeTags(db DBInterface, postID uuid.UUID, categories []string) {
+	for _, category := range categories {
+		name := normalizeTag(category)
+		if name == "" {
+			continue
+		}
+
+		tag, err := db.CreateTag(context.Background(), database.CreateTagParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			Name:      name,
+		})
+		if err != nil {
+			log.Printf("couldn't add tag %s to database: %v", name, err)
+			continue
+		}
+
+		err = db.AddPostTag(context.Background(), database.AddPostTagParams{
+			PostID: postID,
+			TagID:  tag.ID,
+		})
+		if err != nil {
+			log.Printf("couldn't tag post with %s: %v", name, err)
+		}
+	}
+}
+
+// tags are stored lowercased so "Go" and "go" are the same tag
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/test"
+	"github.com/google/uuid"
+)
+
+func TestScrapeFeed_StoresTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><title>Gator Blog</title>
+<item><title>First</title><link>https://example.com/1</link><category>Go</category><category>Web  Dev</category></item>
+<item><title>Second</title><link>https://example.com/2</link><category>go</category></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	scrapeFeed(state, database.Rssfeed{ID: uuid.New(), Name: "gator blog", Url: server.URL})
+
+	if len(mockDb.Tags) != 2 {
+		t.Errorf("expected tags to be shared across posts, got %v", mockDb.Tags)
+	}
+
+	expected := map[int][]string{
+		0: {"go", "web dev"},
+		1: {"go"},
+	}
+	for i, tags := range expected {
+		got, _ := mockDb.GetTagsForPost(context.Background(), mockDb.Posts[i].ID)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tags) {
+			t.Errorf("post %d: expected tags %v, got %v", i, tags, got)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+)
+
+// validation is the output of the validate command, the report of the rss
+// package and the posts gator would store from the feed
+type validation struct {
+	*rss.Report
+	Posts []storedPost `json:"posts"`
+}
+
+type storedPost struct {
+	Title              string    `json:"title"`
+	Url                string    `json:"url"`
+	Guid               string    `json:"guid"`
+	PublishedAt        time.Time `json:"published_at"`
+	PublishedAtGuessed bool      `json:"published_at_guessed"`
+	Author             string    `json:"author,omitempty"`
+	Tags               []string  `json:"tags,omitempty"`
+	Enclosures         []string  `json:"enclosures,omitempty"`
+}
+
+// Handler that fetches a feed without storing it and reports its problems
+// along with the posts gator would store. The feed is read from the same
+// sources addfeed accepts
+func HandlerValidate(s *State, cmd Command) error {
+	flags, args, err := splitFlags(cmd.Arguments, []string{"item", "title", "link", "date", "source", "config"}, "json")
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("usage: %s <url> [--json] [--item <selector> --title <selector> [--link <selector>] [--date <selector>]] [--source <type> [--config <json>]]\n", cmd.Name)
+	}
+
+	feedUrl := args[0]
+	sourceType, sourceConfig, err := sourceFlags(flags)
+	if err != nil {
+		return err
+	}
+	report := httpClient(s).ValidateTyped(context.Background(), sourceType, feedUrl, sourceConfig, feedLimits(s))
+	result := validation{
+		Report: report,
+		Posts:  postsToStore(report.Feed, time.Now().UTC()),
+	}
+
+	if flags["json"] == "true" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("Couldn't encode validation report: %w", err)
+		}
+	} else {
+		printValidation(result)
+	}
+
+	if report.HasErrors() {
+		return fmt.Errorf("%s has problems gator can't work around\n", feedUrl)
+	}
+	return nil
+}
+
+// postsToStore converts the items of the feed the same way scrapeFeed does,
+// items with the identity of an earlier item are left out like the database does
+func postsToStore(feed *rss.RSSFeed, fetchedAt time.Time) []storedPost {
+	posts := []storedPost{}
+	if feed == nil {
+		return posts
+	}
+
+	seen := map[string]bool{}
+	for _, item := range feed.Channel.Item {
+		guid := item.Identity()
+		if seen[guid] {
+			continue
+		}
+		seen[guid] = true
+
+		publishedAt, _ := publishedDate(item, fetchedAt)
+		post := storedPost{
+			Title:              item.Title,
+			Url:                item.Link,
+			Guid:               guid,
+			PublishedAt:        publishedAt.Time,
+			PublishedAtGuessed: publishedAt.Guessed,
+			Author:             item.Author,
+		}
+
+		tagged := map[string]bool{}
+		for _, category := range item.Categories {
+			name := normalizeTag(category)
+			if name != "" && !tagged[name] {
+				tagged[name] = true
+				post.Tags = append(post.Tags, name)
+			}
+		}
+		for _, enclosure := range item.Enclosures {
+			if enclosure.URL != "" {
+				post.Enclosures = append(post.Enclosures, enclosure.URL)
+			}
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+func printValidation(result validation) {
+	report := result.Report
+	fmt.Printf("============ Validating %s ============\n", report.URL)
+	if report.FinalURL != "" {
+		fmt.Printf("Fetched: %s (%s, %s)\n", report.FinalURL, report.Status, report.ContentType)
+	}
+	if report.Format != "" {
+		fmt.Printf("Format: %s\n", report.Format)
+	}
+
+	fmt.Println("-------------PROBLEMS-------------")
+	if len(report.Problems) == 0 {
+		fmt.Println("No problems found")
+	}
+	for _, problem := range report.Problems {
+		where := "feed"
+		if problem.Item > 0 {
+			where = fmt.Sprintf("item %d", problem.Item)
+		}
+		fmt.Printf("%-8s%-22s%-9s%s\n", problem.Severity, problem.Code, where, problem.Message)
+	}
+
+	if report.Feed == nil {
+		return
+	}
+	fmt.Printf("-------------POSTS (%d)-------------\n", len(result.Posts))
+	for _, post := range result.Posts {
+		published := post.PublishedAt.Format(time.RFC1123)
+		if post.PublishedAtGuessed {
+			published += " (guessed)"
+		}
+		fmt.Printf(">%s\n", post.Title)
+		fmt.Printf("  url:%s\n", post.Url)
+		fmt.Printf("  guid:%s\n", post.Guid)
+		fmt.Printf("  published:%s\n", published)
+		if post.Author != "" {
+			fmt.Printf("  author:%s\n", post.Author)
+		}
+		if len(post.Tags) > 0 {
+			fmt.Printf("  tags:%s\n", strings.Join(post.Tags, ", "))
+		}
+		for _, enclosure := range post.Enclosures {
+			fmt.Printf("  media:%s\n", enclosure)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+	"github.com/ManoloEsS/gator_cli/test"
+)
+
+func TestHandlerValidate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		switch r.URL.Path {
+		case "/api":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"hits": [{"id": "1", "title": "Post"}]}`))
+		case "/duplicates":
+			w.Write([]byte(`<rss><channel><title>Blog</title><link>https://example.com/</link>
+<item><title>First</title><guid>1</guid></item>
+<item><title>Again</title><guid>1</guid></item>
+</channel></rss>`))
+		default:
+			w.Write([]byte(`<rss><channel><title>Blog</title><link>https://example.com/</link>
+<item><title>Post</title><link>/post</link><pubDate>2024-05-06</pubDate></item>
+</channel></rss>`))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	feedFile := filepath.Join(dir, "feed.xml")
+	err := os.WriteFile(feedFile, []byte(`<rss><channel><title>Local</title><link>https://example.com/</link>
+<item><title>Post</title><link>https://example.com/post</link><guid>post</guid></item>
+</channel></rss>`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		arguments   []string
+		expectError bool
+	}{
+		{
+			name:      "file feed",
+			arguments: []string{"file://" + filepath.ToSlash(feedFile)},
+		},
+		{
+			name:        "missing file feed",
+			arguments:   []string{"file://" + filepath.ToSlash(filepath.Join(dir, "missing.xml"))},
+			expectError: true,
+		},
+		{
+			name:        "unsupported scheme",
+			arguments:   []string{"gopher://example.com/feed"},
+			expectError: true,
+		},
+		{
+			name:      "json source",
+			arguments: []string{server.URL + "/api", "--source", "json", "--config", `{"items":"hits","title":"title","id":"id"}`},
+		},
+		{
+			name:      "warnings only",
+			arguments: []string{server.URL},
+		},
+		{
+			name:      "json output",
+			arguments: []string{"--json", server.URL},
+		},
+		{
+			name:        "errors",
+			arguments:   []string{server.URL + "/duplicates"},
+			expectError: true,
+		},
+		{
+			name:        "missing url",
+			arguments:   []string{"--json"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &State{
+				Db:  test.NewMockDb(),
+				Cfg: &test.MockCfg{},
+			}
+			err := HandlerValidate(state, Command{Name: "validate", Arguments: tt.arguments})
+
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostsToStore(t *testing.T) {
+	fetchedAt := time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)
+	feed := &rss.RSSFeed{}
+	feed.Channel.Item = []rss.RSSItem{
+		{Title: "First", Link: "https://example.com/1", PubDate: "Mon, 06 May 2024 10:00:00 GMT", Categories: []string{"Go", "go ", "Web  Dev"}, Author: "Ali"},
+		{Title: "Duplicate", Link: "https://example.com/1"},
+		{Title: "Episode", GUID: "ep", Enclosures: []rss.RSSEnclosure{{URL: "https://example.com/ep.mp3"}, {Type: "audio/mpeg"}}},
+	}
+
+	expected := []storedPost{
+		{
+			Title:       "First",
+			Url:         "https://example.com/1",
+			Guid:        "https://example.com/1",
+			PublishedAt: time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+			Author:      "Ali",
+			Tags:        []string{"go", "web dev"},
+		},
+		{
+			Title:              "Episode",
+			Guid:               "ep",
+			PublishedAt:        fetchedAt,
+			PublishedAtGuessed: true,
+			Enclosures:         []string{"https://example.com/ep.mp3"},
+		},
+	}
+
+	got := postsToStore(feed, fetchedAt)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if posts := postsToStore(nil, fetchedAt); len(posts) != 0 {
+		t.Errorf("expected no posts without a feed, got %+v", posts)
+	}
+}
@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+	"github.com/ManoloEsS/gator_cli/internal/websub"
+	"github.com/google/uuid"
+)
+
+const (
+	// how often subscriptions are checked for renewal
+	webSubRenewInterval = 5 * time.Minute
+	// subscriptions are renewed when their lease ends within this margin
+	webSubRenewMargin = time.Hour
+	// hubs that didn't verify a request are asked again after this wait
+	webSubRetryInterval = 15 * time.Minute
+)
+
+// Handler that subscribes to the WebSub hubs advertised by the feeds and
+// stores the posts the hubs push, until it is interrupted
+func HandlerWebSub(s *State, cmd Command) error {
+	flags, args, err := splitFlags(cmd.Arguments, []string{"listen"})
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("usage: %s <callback-url> [--listen <address>]\n", cmd.Name)
+	}
+
+	callbackUrl, err := url.Parse(args[0])
+	if err != nil || (callbackUrl.Scheme != "http" && callbackUrl.Scheme != "https") || callbackUrl.Host == "" {
+		return fmt.Errorf("callback url must be an absolute http or https url the hubs can reach\n")
+	}
+	listen := flags["listen"]
+	if listen == "" {
+		listen = ":8080"
+	}
+
+	// the listener accepts connections before any subscription request goes
+	// out, hubs can verify the intent while the request is being answered
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("Couldn't listen on %s: %w", listen, err)
+	}
+
+	subscriber := newWebSubscriber(s, args[0])
+	server := &http.Server{
+		Handler: subscriber,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	fmt.Printf("Listening on %s for hub requests to %s/<feed-id>\n", listen, subscriber.callbackBase)
+
+	ticker := time.NewTicker(webSubRenewInterval)
+	defer ticker.Stop()
+	for {
+		subscriber.renew(time.Now().UTC())
+		select {
+		case err := <-serveErr:
+			return fmt.Errorf("Couldn't run WebSub callback server: %w", err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// webSubscriber sends the subscription requests and answers the hubs at the
+// callback urls, one per feed: <callbackBase>/<feed id>
+type webSubscriber struct {
+	s            *State
+	callbackBase string
+	client       *http.Client
+}
+
+func newWebSubscriber(s *State, callbackBase string) *webSubscriber {
+	return &webSubscriber{
+		s:            s,
+		callbackBase: strings.TrimSuffix(callbackBase, "/"),
+		client:       httpClient(s).HTTPClient(),
+	}
+}
+
+func (ws *webSubscriber) callbackUrl(feedID uuid.UUID) string {
+	return ws.callbackBase + "/" + feedID.String()
+}
+
+// renew sends a subscription request for the feeds with a hub that aren't
+// subscribed yet or whose lease is about to end
+func (ws *webSubscriber) renew(now time.Time) {
+	db := ws.s.Db
+	subs, err := db.GetSubscriptionsToRenew(context.Background(), database.GetSubscriptionsToRenewParams{
+		RenewBefore: now.Add(webSubRenewMargin),
+		RetryBefore: now.Add(-webSubRetryInterval),
+	})
+	if err != nil {
+		log.Printf("couldn't get subscriptions to renew: %v", err)
+		return
+	}
+
+	for _, sub := range subs {
+		// secrets are only sent to hubs over https
+		secret := ""
+		if strings.HasPrefix(sub.Hub, "https://") {
+			secret = sub.Secret
+			if secret == "" {
+				secret, err = websub.NewSecret()
+				if err != nil {
+					log.Printf("couldn't subscribe to %s: %v", sub.Topic, err)
+					continue
+				}
+			}
+		}
+
+		err = db.MarkSubscriptionRequested(context.Background(), database.MarkSubscriptionRequestedParams{
+			Secret:      secret,
+			RequestedAt: now,
+			Callback:    ws.callbackUrl(sub.FeedID),
+			FeedID:      sub.FeedID,
+		})
+		if err != nil {
+			log.Printf("couldn't store subscription to %s: %v", sub.Topic, err)
+			continue
+		}
+
+		err = websub.Subscribe(context.Background(), ws.client, websub.Request{
+			Hub:      sub.Hub,
+			Topic:    sub.Topic,
+			Callback: ws.callbackUrl(sub.FeedID),
+			Secret:   secret,
+			Lease:    websub.DefaultLease,
+		})
+		if err != nil {
+			log.Printf("couldn't subscribe to %s at %s: %v", sub.Topic, sub.Hub, err)
+			continue
+		}
+		log.Printf("Subscription to %s requested at %s", sub.Topic, sub.Hub)
+	}
+}
+
+func (ws *webSubscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	feedID, err := uuid.Parse(path.Base(r.URL.Path))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ws.verify(w, r, feedID)
+	case http.MethodPost:
+		ws.deliver(w, r, feedID)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify answers the verification of intent, confirming only the requests
+// gator made by echoing the challenge
+func (ws *webSubscriber) verify(w http.ResponseWriter, r *http.Request, feedID uuid.UUID) {
+	intent, err := websub.ParseIntent(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db := ws.s.Db
+	sub, err := db.GetSubscription(r.Context(), feedID)
+	wanted := err == nil && sub.Topic == intent.Topic
+
+	switch intent.Mode {
+	case websub.ModeSubscribe:
+		if !wanted {
+			http.NotFound(w, r)
+			return
+		}
+		err = db.SetSubscriptionLease(r.Context(), database.SetSubscriptionLeaseParams{
+			FeedID:         feedID,
+			LeaseExpiresAt: newNullTime(time.Now().UTC().Add(intent.Lease)),
+		})
+		if err != nil {
+			log.Printf("couldn't store lease of subscription to %s: %v", intent.Topic, err)
+			http.Error(w, "couldn't store subscription", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Subscribed to %s for %s", intent.Topic, intent.Lease)
+	case websub.ModeUnsubscribe:
+		// a subscription not requested yet is to a new hub of the feed, the
+		// old hub verifies the cancelling of the subscription to the topic
+		if wanted && sub.RequestedAt.Valid {
+			http.NotFound(w, r)
+			return
+		}
+	case websub.ModeDenied:
+		if wanted {
+			err = db.SetSubscriptionLease(r.Context(), database.SetSubscriptionLeaseParams{FeedID: feedID})
+			if err != nil {
+				log.Printf("couldn't remove lease of subscription to %s: %v", intent.Topic, err)
+			}
+		}
+		log.Printf("Hub denied subscription to %s: %s", intent.Topic, intent.Reason)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, intent.Challenge)
+}
+
+// deliver stores the posts of the content pushed by the hub. Content with a
+// missing or wrong signature is acknowledged but ignored, as WebSub requires
+func (ws *webSubscriber) deliver(w http.ResponseWriter, r *http.Request, feedID uuid.UUID) {
+	db := ws.s.Db
+	sub, err := db.GetSubscription(r.Context(), feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// tells the hub to drop the subscription
+		http.Error(w, "no subscription", http.StatusGone)
+		return
+	}
+	if err != nil {
+		log.Printf("couldn't get subscription of feed %s: %v", feedID, err)
+		http.Error(w, "couldn't get subscription", http.StatusInternalServerError)
+		return
+	}
+
+	maxBodySize := feedLimits(ws.s).MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = rss.DefaultLimits.MaxBodySize
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "couldn't read content", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if sub.Secret != "" && !websub.ValidSignature(sub.Secret, body, r.Header.Get("X-Hub-Signature")) {
+		log.Printf("Ignored content for %s with an invalid signature", sub.Topic)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	feed, err := db.GetFeedByID(r.Context(), feedID)
+	if err != nil {
+		log.Printf("couldn't get feed %s: %v", feedID, err)
+		http.Error(w, "couldn't get feed", http.StatusInternalServerError)
+		return
+	}
+
+	// pushed content can be a part of the feed, so it only adds posts and
+	// leaves the polling schedule of the feed alone
+	topicUrl, _ := url.Parse(sub.Topic)
+	fetchResult, err := ingestFeed(ws.s, feed, rss.ReaderSource{
+		Reader:      bytes.NewReader(body),
+		BaseURL:     topicUrl,
+		ContentType: r.Header.Get("Content-Type"),
+	})
+	if err != nil {
+		log.Printf("couldn't read content pushed for %s: %v", sub.Topic, err)
+		http.Error(w, "couldn't read content", http.StatusBadRequest)
+		return
+	}
+	logFetch(feed, fetchResult, nil)
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,362 @@
+package cli
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/rss"
+	"github.com/ManoloEsS/gator_cli/test"
+	"github.com/google/uuid"
+)
+
+func TestScrapeFeed_WebSubHub(t *testing.T) {
+	// hub stand-ins, recording the requests they get
+	var requests []url.Values
+	hubHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r.PostForm)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	hub := httptest.NewServer(hubHandler)
+	defer hub.Close()
+	newHub := httptest.NewServer(hubHandler)
+	defer newHub.Close()
+
+	hubUrl := hub.URL
+	body := func() string {
+		if hubUrl == "" {
+			return `<rss><channel><title>Pushed</title></channel></rss>`
+		}
+		return `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Pushed</title>
+<atom:link rel="hub" href="` + hubUrl + `"/><atom:link rel="self" href="https://example.com/feed.xml"/>
+</channel></rss>`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body()))
+	}))
+	defer server.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	feed := database.Rssfeed{ID: uuid.New(), Name: "pushed", Url: server.URL}
+	callback := "https://gator.example.com/websub/" + feed.ID.String()
+	subscribe := func() {
+		sub := mockDb.Subscriptions[feed.ID]
+		sub.RequestedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		sub.Callback = callback
+		mockDb.Subscriptions[feed.ID] = sub
+	}
+
+	scrapeFeed(state, feed)
+	sub, ok := mockDb.Subscriptions[feed.ID]
+	if !ok {
+		t.Fatalf("expected the hub of the feed to be stored")
+	}
+	if sub.Hub != hub.URL || sub.Topic != "https://example.com/feed.xml" {
+		t.Errorf("expected hub and self link as topic, got %+v", sub)
+	}
+	if len(requests) != 0 {
+		t.Errorf("expected no request to a hub the feed isn't subscribed at, got %v", requests)
+	}
+
+	subscribe()
+	scrapeFeed(state, feed)
+	if len(requests) != 0 {
+		t.Errorf("expected the subscription to be kept while the feed advertises the hub, got %v", requests)
+	}
+
+	hubUrl = newHub.URL
+	scrapeFeed(state, feed)
+	if sub := mockDb.Subscriptions[feed.ID]; sub.Hub != newHub.URL || sub.RequestedAt.Valid {
+		t.Errorf("expected the new hub to be stored to subscribe at, got %+v", sub)
+	}
+	if len(requests) != 1 || requests[0].Get("hub.mode") != "unsubscribe" ||
+		requests[0].Get("hub.topic") != "https://example.com/feed.xml" || requests[0].Get("hub.callback") != callback {
+		t.Fatalf("expected an unsubscription at the old hub, got %v", requests)
+	}
+
+	subscribe()
+	hubUrl = ""
+	scrapeFeed(state, feed)
+	if _, ok := mockDb.Subscriptions[feed.ID]; ok {
+		t.Errorf("expected the hub to be removed when the feed stops advertising it")
+	}
+	if len(requests) != 2 || requests[1].Get("hub.mode") != "unsubscribe" {
+		t.Errorf("expected an unsubscription when the feed stops advertising the hub, got %v", requests)
+	}
+}
+
+func TestHandlerWebSub_ListenError(t *testing.T) {
+	var requests int
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	// the address is taken, so the callback listener can't start
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:  mockDb,
+		Cfg: &test.MockCfg{},
+	}
+	feedID := uuid.New()
+	mockDb.Subscriptions[feedID] = database.WebsubSubscription{
+		FeedID: feedID,
+		Hub:    hub.URL,
+		Topic:  "https://example.com/feed.xml",
+	}
+
+	err = HandlerWebSub(state, Command{
+		Name:      "websub",
+		Arguments: []string{"https://gator.example.com/websub", "--listen", busy.Addr().String()},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Couldn't listen") {
+		t.Errorf("expected a listen error, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no subscription request without a listener, got %d", requests)
+	}
+}
+
+func TestWebSubscriber(t *testing.T) {
+	// hub stand-in, recording the subscription requests
+	var requests []url.Values
+	hub := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r.PostForm)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	bundle := filepath.Join(t.TempDir(), "hub.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: hub.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := rss.NewClient(rss.ClientOptions{CABundle: bundle})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	mockDb := test.NewMockDb()
+	state := &State{
+		Db:   mockDb,
+		Cfg:  &test.MockCfg{},
+		HTTP: client,
+	}
+	feed := database.Rssfeed{ID: uuid.New(), Name: "pushed", Url: "https://example.com/feed.xml"}
+	mockDb.Feeds[feed.Url] = feed
+	mockDb.Subscriptions[feed.ID] = database.WebsubSubscription{
+		FeedID: feed.ID,
+		Hub:    hub.URL,
+		Topic:  feed.Url,
+	}
+
+	subscriber := newWebSubscriber(state, "https://gator.example.com/websub/")
+	callback := httptest.NewServer(subscriber)
+	defer callback.Close()
+	callbackPath := callback.URL + "/websub/" + feed.ID.String()
+
+	now := time.Now().UTC()
+	subscriber.renew(now)
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 subscription request, got %d", len(requests))
+	}
+	request := requests[0]
+	if request.Get("hub.mode") != "subscribe" || request.Get("hub.topic") != feed.Url {
+		t.Errorf("expected a subscription to the feed, got %v", request)
+	}
+	if request.Get("hub.callback") != "https://gator.example.com/websub/"+feed.ID.String() {
+		t.Errorf("expected the callback url of the feed, got %q", request.Get("hub.callback"))
+	}
+	secret := request.Get("hub.secret")
+	if secret == "" || mockDb.Subscriptions[feed.ID].Secret != secret {
+		t.Errorf("expected the secret to be sent and stored")
+	}
+
+	subscriber.renew(now.Add(time.Minute))
+	if len(requests) != 1 {
+		t.Errorf("expected no new request while the hub verifies, got %d requests", len(requests))
+	}
+
+	t.Run("verification of intent", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			query          string
+			expectedStatus int
+			expectedBody   string
+		}{
+			{
+				name:           "other topic",
+				query:          "hub.mode=subscribe&hub.topic=https://example.com/other.xml&hub.challenge=abc&hub.lease_seconds=3600",
+				expectedStatus: http.StatusNotFound,
+			},
+			{
+				name:           "unsubscribe from a wanted feed",
+				query:          "hub.mode=unsubscribe&hub.topic=https://example.com/feed.xml&hub.challenge=abc",
+				expectedStatus: http.StatusNotFound,
+			},
+			{
+				name:           "invalid request",
+				query:          "hub.mode=subscribe&hub.topic=https://example.com/feed.xml",
+				expectedStatus: http.StatusBadRequest,
+			},
+			{
+				name:           "subscribe",
+				query:          "hub.mode=subscribe&hub.topic=https://example.com/feed.xml&hub.challenge=abc&hub.lease_seconds=86400",
+				expectedStatus: http.StatusOK,
+				expectedBody:   "abc",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resp, err := http.Get(callbackPath + "?" + tt.query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != tt.expectedStatus {
+					t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+				}
+				if tt.expectedBody != "" {
+					body, _ := io.ReadAll(resp.Body)
+					if string(body) != tt.expectedBody {
+						t.Errorf("expected the challenge to be echoed, got %q", body)
+					}
+				}
+			})
+		}
+	})
+
+	lease := mockDb.Subscriptions[feed.ID].LeaseExpiresAt
+	if !lease.Valid || lease.Time.Before(now.Add(23*time.Hour)) {
+		t.Errorf("expected the granted lease to be stored, got %+v", lease)
+	}
+
+	t.Run("content distribution", func(t *testing.T) {
+		content := `<feed xmlns="http://www.w3.org/2005/Atom"><title>Pushed</title>
+<entry><id>1</id><title>Pushed post</title><link href="/pushed"/></entry></feed>`
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(content))
+		signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		tests := []struct {
+			name           string
+			path           string
+			signature      string
+			expectedStatus int
+			expectedPosts  int
+		}{
+			{
+				name:           "missing signature",
+				path:           callbackPath,
+				expectedStatus: http.StatusAccepted,
+			},
+			{
+				name:           "wrong signature",
+				path:           callbackPath,
+				signature:      "sha256=" + strings.Repeat("0", 64),
+				expectedStatus: http.StatusAccepted,
+			},
+			{
+				name:           "unknown subscription",
+				path:           callback.URL + "/websub/" + uuid.New().String(),
+				signature:      signature,
+				expectedStatus: http.StatusGone,
+			},
+			{
+				name:           "signed content",
+				path:           callbackPath,
+				signature:      signature,
+				expectedStatus: http.StatusAccepted,
+				expectedPosts:  1,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req, err := http.NewRequest("POST", tt.path, strings.NewReader(content))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Content-Type", "application/atom+xml")
+				if tt.signature != "" {
+					req.Header.Set("X-Hub-Signature", tt.signature)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+
+				if resp.StatusCode != tt.expectedStatus {
+					t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+				}
+				if len(mockDb.Posts) != tt.expectedPosts {
+					t.Fatalf("expected %d posts, got %d", tt.expectedPosts, len(mockDb.Posts))
+				}
+			})
+		}
+
+		if got := mockDb.Posts[0].Url; got != "https://example.com/pushed" {
+			t.Errorf("expected the link resolved against the topic, got %q", got)
+		}
+		if next := mockDb.Feeds[feed.Url].NextFetchAt; next.Valid {
+			t.Errorf("expected pushed content to leave the schedule of the feed alone, got %v", next.Time)
+		}
+	})
+
+	t.Run("polling", func(t *testing.T) {
+		reports := make(chan fetchReport, 1)
+		fetchDueFeeds(context.Background(), state, now.Add(time.Hour), reports)
+		if len(reports) != 0 {
+			t.Errorf("expected a feed pushed by its hub not to be polled")
+		}
+
+		_, err := mockDb.ClaimNextFeedToFetch(context.Background(), database.ClaimNextFeedToFetchParams{
+			Now:          lease.Time.Add(time.Minute),
+			ClaimedUntil: lease.Time.Add(time.Hour),
+		})
+		if err != nil {
+			t.Errorf("expected the feed to be polled again once the lease is over, got %v", err)
+		}
+	})
+
+	subscriber.renew(now.Add(20 * time.Hour))
+	if len(requests) != 1 {
+		t.Errorf("expected no renewal while the lease lasts, got %d requests", len(requests))
+	}
+	subscriber.renew(now.Add(23*time.Hour + 30*time.Minute))
+	if len(requests) != 2 {
+		t.Fatalf("expected the subscription to be renewed before the lease ends, got %d requests", len(requests))
+	}
+	if requests[1].Get("hub.secret") != secret {
+		t.Errorf("expected the renewal to keep the secret")
+	}
+}
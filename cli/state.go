@@ -2,9 +2,13 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/ManoloEsS/gator_cli/internal/config"
 	"github.com/ManoloEsS/gator_cli/internal/database"
+	"github.com/ManoloEsS/gator_cli/internal/rss"
 	"github.com/google/uuid"
 )
 
@@ -21,19 +25,46 @@ type DBInterface interface {
 	CreateRSSFeed(ctx context.Context, arg database.CreateRSSFeedParams) (database.CreateRSSFeedRow, error)
 	GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error)
 	GetFeedByUrl(ctx context.Context, url string) (database.Rssfeed, error)
+	GetFeedByID(ctx context.Context, id uuid.UUID) (database.Rssfeed, error)
+	GetFeedsByName(ctx context.Context, name string) ([]database.Rssfeed, error)
+	GetFollowedFeedsByName(ctx context.Context, arg database.GetFollowedFeedsByNameParams) ([]database.Rssfeed, error)
 	CreateFeedFollow(ctx context.Context, params database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error)
 	GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error)
 	UnfollowFeed(ctx context.Context, arg database.UnfollowFeedParams) error
 	MarkFeedFetched(ctx context.Context, id uuid.UUID) error
-	GetNextFeedToFetch(ctx context.Context) (database.Rssfeed, error)
+	ClaimNextFeedToFetch(ctx context.Context, arg database.ClaimNextFeedToFetchParams) (database.Rssfeed, error)
+	RenewFeedClaim(ctx context.Context, arg database.RenewFeedClaimParams) (int64, error)
+	SetFeedNextFetch(ctx context.Context, arg database.SetFeedNextFetchParams) error
+	MoveFeed(ctx context.Context, arg database.MoveFeedParams) error
+	UpdateFeedCache(ctx context.Context, arg database.UpdateFeedCacheParams) error
+	SetFeedFullArticle(ctx context.Context, arg database.SetFeedFullArticleParams) error
 	CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error)
+	AdoptLegacyPost(ctx context.Context, arg database.AdoptLegacyPostParams) (int64, error)
+	DeleteLegacyDuplicate(ctx context.Context, id uuid.UUID) (int64, error)
 	GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error)
+	GetPostForUser(ctx context.Context, arg database.GetPostForUserParams) (database.GetPostForUserRow, error)
+	SetPostArticle(ctx context.Context, arg database.SetPostArticleParams) error
+	CreatePostEnclosure(ctx context.Context, arg database.CreatePostEnclosureParams) (database.PostEnclosure, error)
+	GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]database.PostEnclosure, error)
+	GetEnclosuresForUser(ctx context.Context, arg database.GetEnclosuresForUserParams) ([]database.GetEnclosuresForUserRow, error)
+	GetPostsForUserByTag(ctx context.Context, arg database.GetPostsForUserByTagParams) ([]database.GetPostsForUserByTagRow, error)
+	CreateTag(ctx context.Context, arg database.CreateTagParams) (database.Tag, error)
+	AddPostTag(ctx context.Context, arg database.AddPostTagParams) error
+	GetTagsForPost(ctx context.Context, postID uuid.UUID) ([]string, error)
+	GetTagsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetTagsForUserRow, error)
+	SetFeedHub(ctx context.Context, arg database.SetFeedHubParams) error
+	DeleteFeedHub(ctx context.Context, feedID uuid.UUID) error
+	GetSubscription(ctx context.Context, feedID uuid.UUID) (database.WebsubSubscription, error)
+	GetSubscriptionsToRenew(ctx context.Context, arg database.GetSubscriptionsToRenewParams) ([]database.WebsubSubscription, error)
+	MarkSubscriptionRequested(ctx context.Context, arg database.MarkSubscriptionRequestedParams) error
+	SetSubscriptionLease(ctx context.Context, arg database.SetSubscriptionLeaseParams) error
 }
 
 // ConfigInterface defines the config operations needed by Config Interface
 type ConfigInterface interface {
 	SetUser(name string) error
 	GetCurrentUser() string
+	GetFeedLimits() config.FeedLimits
 }
 
 // State struct that stores the Database and Config interfaces
@@ -41,12 +72,56 @@ type ConfigInterface interface {
 type State struct {
 	Db  DBInterface
 	Cfg ConfigInterface
+	// Stdin is read by commands that take input from a pipe, os.Stdin when nil
+	Stdin io.Reader
+	// HTTP is the client shared by every fetch, rss.DefaultClient when nil
+	HTTP *rss.Client
 }
 
-// NewState creates a new State with the interfaces
-func NewState(db *database.Queries, cfg *config.Config) *State {
+// NewState creates a new State with the interfaces and the HTTP client
+// configured in the config file
+func NewState(db *database.Queries, cfg *config.Config) (*State, error) {
+	client, err := newHTTPClient(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
 	return &State{
-		Db:  db,
-		Cfg: cfg,
+		Db:   db,
+		Cfg:  cfg,
+		HTTP: client,
+	}, nil
+}
+
+// newHTTPClient builds the rss client from the http section of the config file
+func newHTTPClient(cfg config.HTTPConfig) (*rss.Client, error) {
+	opts := rss.ClientOptions{
+		Retries:   cfg.Retries,
+		ProxyURL:  cfg.Proxy,
+		CABundle:  cfg.CABundle,
+		UserAgent: cfg.UserAgent,
+	}
+	var err error
+	if cfg.Timeout != "" {
+		if opts.Timeout, err = time.ParseDuration(cfg.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid http timeout in config: %w", err)
+		}
+	}
+	if cfg.Backoff != "" {
+		if opts.Backoff, err = time.ParseDuration(cfg.Backoff); err != nil {
+			return nil, fmt.Errorf("invalid http backoff in config: %w", err)
+		}
+	}
+
+	client, err := rss.NewClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http config: %w", err)
+	}
+	return client, nil
+}
+
+func httpClient(s *State) *rss.Client {
+	if s.HTTP != nil {
+		return s.HTTP
 	}
+	return rss.DefaultClient
 }
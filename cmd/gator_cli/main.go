@@ -48,7 +48,10 @@ func main() {
 	//add database and its function=>queries to program state
 	dbQueries := database.New(db)
 
-	programState := cli.NewState(dbQueries, &cfg)
+	programState, err := cli.NewState(dbQueries, &cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	cmds := cli.Commands{
 		CommandMap: make(map[string]func(*cli.State, cli.Command) error),
@@ -60,12 +63,21 @@ func main() {
 	cmds.Register("reset", cli.HandlerReset)
 	cmds.Register("users", cli.HandlerListUsers)
 	cmds.Register("agg", cli.HandlerAgg)
+	cmds.Register("websub", cli.HandlerWebSub)
 	cmds.Register("addfeed", cli.MiddlewareLoggedIn(cli.HandlerAddFeed))
 	cmds.Register("feeds", cli.HandlerListFeeds)
+	cmds.Register("discover", cli.HandlerDiscover)
+	cmds.Register("validate", cli.HandlerValidate)
+	cmds.Register("ingest", cli.HandlerIngest)
+	cmds.Register("fullarticle", cli.MiddlewareLoggedIn(cli.HandlerFullArticle))
 	cmds.Register("follow", cli.MiddlewareLoggedIn(cli.HandlerFeedFollow))
 	cmds.Register("following", cli.MiddlewareLoggedIn(cli.HandlerFeedFollowsForUser))
 	cmds.Register("unfollow", cli.MiddlewareLoggedIn(cli.HandlerUnfollowFeed))
 	cmds.Register("browse", cli.MiddlewareLoggedIn(cli.HandlerBrowse))
+	cmds.Register("read", cli.MiddlewareLoggedIn(cli.HandlerRead))
+	cmds.Register("newsletters", cli.MiddlewareLoggedIn(cli.HandlerNewsletters))
+	cmds.Register("enclosures", cli.MiddlewareLoggedIn(cli.HandlerListEnclosures))
+	cmds.Register("tags", cli.MiddlewareLoggedIn(cli.HandlerListTags))
 
 	//run command from parsed command line arguments
 	err = cmds.Run(programState, cmd)
@@ -21,8 +21,29 @@ var getConfigFilePath = func() (string, error) {
 }
 
 type Config struct {
-	DbUrl           string `json:"db_url"`
-	CurrentUserName string `json:"current_user_name"`
+	DbUrl           string     `json:"db_url"`
+	CurrentUserName string     `json:"current_user_name"`
+	FeedLimits      FeedLimits `json:"feed_limits,omitzero"`
+	HTTP            HTTPConfig `json:"http,omitzero"`
+}
+
+// FeedLimits bound how much of a feed is read on each fetch,
+// zero values fall back to the rss package defaults
+type FeedLimits struct {
+	MaxBodySize int64 `json:"max_body_size,omitempty"`
+	MaxItems    int   `json:"max_items,omitempty"`
+}
+
+// HTTPConfig configures the client feeds are fetched with. Timeout and Backoff
+// are durations like "10s", zero values fall back to the rss package defaults.
+// Retries is nil when the file doesn't set it, a Retries of 0 disables retries
+type HTTPConfig struct {
+	Timeout   string `json:"timeout,omitempty"`
+	Retries   *int   `json:"retries,omitempty"`
+	Backoff   string `json:"backoff,omitempty"`
+	Proxy     string `json:"proxy,omitempty"`
+	CABundle  string `json:"ca_bundle,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
 }
 
 // Config method that sets the username passed as the current username in the state's config and
@@ -36,6 +57,10 @@ func (cfg *Config) GetCurrentUser() string {
 	return cfg.CurrentUserName
 }
 
+func (cfg *Config) GetFeedLimits() FeedLimits {
+	return cfg.FeedLimits
+}
+
 // Function that reads the config file and extracts the json data as a Config struct
 func Read() (Config, error) {
 	fullPath, err := getConfigFilePath()
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -128,6 +129,46 @@ func TestRead(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "read config with feed limits",
+			setupFile: func(path string) error {
+				return os.WriteFile(path, []byte(`{"db_url":"postgres://localhost/test","feed_limits":{"max_body_size":1048576,"max_items":50}}`), 0644)
+			},
+			expectedConfig: Config{
+				DbUrl:      "postgres://localhost/test",
+				FeedLimits: FeedLimits{MaxBodySize: 1048576, MaxItems: 50},
+			},
+			expectError: false,
+		},
+		{
+			name: "read config with http settings",
+			setupFile: func(path string) error {
+				return os.WriteFile(path, []byte(`{"db_url":"postgres://localhost/test","http":{"timeout":"20s","retries":3,"backoff":"2s","proxy":"http://proxy.corp:3128","ca_bundle":"/etc/ssl/corp.pem","user_agent":"Corp Reader"}}`), 0644)
+			},
+			expectedConfig: Config{
+				DbUrl: "postgres://localhost/test",
+				HTTP: HTTPConfig{
+					Timeout:   "20s",
+					Retries:   intPtr(3),
+					Backoff:   "2s",
+					Proxy:     "http://proxy.corp:3128",
+					CABundle:  "/etc/ssl/corp.pem",
+					UserAgent: "Corp Reader",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "read config with retries disabled",
+			setupFile: func(path string) error {
+				return os.WriteFile(path, []byte(`{"db_url":"postgres://localhost/test","http":{"retries":0}}`), 0644)
+			},
+			expectedConfig: Config{
+				DbUrl: "postgres://localhost/test",
+				HTTP:  HTTPConfig{Retries: intPtr(0)},
+			},
+			expectError: false,
+		},
 		{
 			name: "read non-existent config",
 			setupFile: func(path string) error {
@@ -190,6 +231,14 @@ func TestRead(t *testing.T) {
 			if config.CurrentUserName != tt.expectedConfig.CurrentUserName {
 				t.Errorf("expected CurrentUserName = %q, got %q", tt.expectedConfig.CurrentUserName, config.CurrentUserName)
 			}
+
+			if config.FeedLimits != tt.expectedConfig.FeedLimits {
+				t.Errorf("expected FeedLimits = %+v, got %+v", tt.expectedConfig.FeedLimits, config.FeedLimits)
+			}
+
+			if !reflect.DeepEqual(config.HTTP, tt.expectedConfig.HTTP) {
+				t.Errorf("expected HTTP = %+v, got %+v", tt.expectedConfig.HTTP, config.HTTP)
+			}
 		})
 	}
 }
@@ -293,4 +342,8 @@ func TestGetConfigFilePath(t *testing.T) {
 	if path != expectedPath {
 		t.Errorf("expected path = %q, got %q", expectedPath, path)
 	}
-}
\ No newline at end of file
+}
+
+func intPtr(n int) *int {
+	return &n
+}
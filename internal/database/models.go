@@ -6,11 +6,18 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type FeedAlias struct {
+	Url       string
+	CreatedAt time.Time
+	FeedID    uuid.UUID
+}
+
 type FeedFollow struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
@@ -20,24 +27,61 @@ type FeedFollow struct {
 }
 
 type Post struct {
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Title              string
+	Url                string
+	Description        sql.NullString
+	PublishedAt        sql.NullTime
+	FeedID             uuid.UUID
+	Guid               string
+	LegacyGuid         bool
+	Author             sql.NullString
+	Content            sql.NullString
+	PublishedAtGuessed bool
+	Article            sql.NullString
+}
+
+type PostEnclosure struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
-	Title       string
+	PostID      uuid.UUID
 	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	Length      sql.NullInt64
+	MimeType    sql.NullString
+	Duration    sql.NullString
+	Episode     sql.NullInt32
+	Season      sql.NullInt32
+	EpisodeType sql.NullString
+}
+
+type PostTag struct {
+	PostID uuid.UUID
+	TagID  uuid.UUID
 }
 
 type Rssfeed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Name             string
+	Url              string
+	UserID           uuid.UUID
+	LastFetchedAt    sql.NullTime
+	Etag             sql.NullString
+	LastModified     sql.NullString
+	NextFetchAt      sql.NullTime
+	FetchFullArticle bool
+	SourceType       string
+	SourceConfig     json.RawMessage
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Name      string
 }
 
 type User struct {
@@ -46,3 +90,15 @@ type User struct {
 	UpdatedAt time.Time
 	Name      string
 }
+
+type WebsubSubscription struct {
+	FeedID         uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Hub            string
+	Topic          string
+	Secret         string
+	RequestedAt    sql.NullTime
+	LeaseExpiresAt sql.NullTime
+	Callback       string
+}
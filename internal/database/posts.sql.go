@@ -13,8 +13,32 @@ import (
 	"github.com/google/uuid"
 )
 
+const adoptLegacyPost = `-- name: AdoptLegacyPost :execrows
+UPDATE posts
+SET guid = $3,
+legacy_guid = false,
+updated_at = NOW()
+WHERE feed_id = $1 AND url = $2 AND legacy_guid
+`
+
+type AdoptLegacyPostParams struct {
+	FeedID uuid.UUID
+	Url    string
+	Guid   string
+}
+
+// posts stored before guids were tracked are keyed by their link, the first
+// item fetched again with that link gives them its guid
+func (q *Queries) AdoptLegacyPost(ctx context.Context, arg AdoptLegacyPostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, adoptLegacyPost, arg.FeedID, arg.Url, arg.Guid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, author, content, published_at_guessed)
 VALUES (
     $1,
     $2,
@@ -23,20 +47,28 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12
     )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, legacy_guid, author, content, published_at_guessed, article
 `
 
 type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Title              string
+	Url                string
+	Description        sql.NullString
+	PublishedAt        sql.NullTime
+	FeedID             uuid.UUID
+	Guid               string
+	Author             sql.NullString
+	Content            sql.NullString
+	PublishedAtGuessed bool
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -49,6 +81,10 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Guid,
+		arg.Author,
+		arg.Content,
+		arg.PublishedAtGuessed,
 	)
 	var i Post
 	err := row.Scan(
@@ -60,12 +96,91 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Guid,
+		&i.LegacyGuid,
+		&i.Author,
+		&i.Content,
+		&i.PublishedAtGuessed,
+		&i.Article,
+	)
+	return i, err
+}
+
+const deleteLegacyDuplicate = `-- name: DeleteLegacyDuplicate :execrows
+DELETE FROM posts
+WHERE posts.id = $1
+AND EXISTS (
+    SELECT 1 FROM posts legacy
+    WHERE legacy.feed_id = posts.feed_id
+    AND legacy.url = posts.url
+    AND legacy.legacy_guid
+)
+`
+
+// a post just created for an item whose link is the key of a legacy post is
+// removed, so the legacy post can take its guid instead
+func (q *Queries) DeleteLegacyDuplicate(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteLegacyDuplicate, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPostForUser = `-- name: GetPostForUser :one
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.content, posts.published_at_guessed, posts.article, rssfeeds.name AS feed_name
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN rssfeeds ON posts.feed_id = rssfeeds.id
+WHERE feed_follows.user_id = $1
+AND (posts.id::text = $2::text OR posts.url = $2::text)
+ORDER BY posts.published_at DESC LIMIT 1
+`
+
+type GetPostForUserParams struct {
+	UserID uuid.UUID
+	Post   string
+}
+
+type GetPostForUserRow struct {
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Title              string
+	Url                string
+	Description        sql.NullString
+	PublishedAt        sql.NullTime
+	FeedID             uuid.UUID
+	Author             sql.NullString
+	Content            sql.NullString
+	PublishedAtGuessed bool
+	Article            sql.NullString
+	FeedName           string
+}
+
+func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams) (GetPostForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostForUser, arg.UserID, arg.Post)
+	var i GetPostForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Author,
+		&i.Content,
+		&i.PublishedAtGuessed,
+		&i.Article,
+		&i.FeedName,
 	)
 	return i, err
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, rssfeeds.name AS feed_name
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.content, posts.published_at_guessed, rssfeeds.name AS feed_name
 FROM posts
 INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 INNER JOIN rssfeeds ON posts.feed_id = rssfeeds.id 
@@ -79,15 +194,18 @@ type GetPostsForUserParams struct {
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Title              string
+	Url                string
+	Description        sql.NullString
+	PublishedAt        sql.NullTime
+	FeedID             uuid.UUID
+	Author             sql.NullString
+	Content            sql.NullString
+	PublishedAtGuessed bool
+	FeedName           string
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
@@ -108,6 +226,78 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Author,
+			&i.Content,
+			&i.PublishedAtGuessed,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserByTag = `-- name: GetPostsForUserByTag :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.content, posts.published_at_guessed, rssfeeds.name AS feed_name
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN rssfeeds ON posts.feed_id = rssfeeds.id
+INNER JOIN post_tags ON post_tags.post_id = posts.id
+INNER JOIN tags ON post_tags.tag_id = tags.id
+WHERE feed_follows.user_id = $1
+AND tags.name = $2
+ORDER BY posts.published_at DESC LIMIT $3
+`
+
+type GetPostsForUserByTagParams struct {
+	UserID uuid.UUID
+	Name   string
+	Limit  int32
+}
+
+type GetPostsForUserByTagRow struct {
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Title              string
+	Url                string
+	Description        sql.NullString
+	PublishedAt        sql.NullTime
+	FeedID             uuid.UUID
+	Author             sql.NullString
+	Content            sql.NullString
+	PublishedAtGuessed bool
+	FeedName           string
+}
+
+func (q *Queries) GetPostsForUserByTag(ctx context.Context, arg GetPostsForUserByTagParams) ([]GetPostsForUserByTagRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByTag, arg.UserID, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserByTagRow
+	for rows.Next() {
+		var i GetPostsForUserByTagRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.Content,
+			&i.PublishedAtGuessed,
 			&i.FeedName,
 		); err != nil {
 			return nil, err
@@ -122,3 +312,20 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const setPostArticle = `-- name: SetPostArticle :exec
+UPDATE posts
+SET article = $2,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type SetPostArticleParams struct {
+	ID      uuid.UUID
+	Article sql.NullString
+}
+
+func (q *Queries) SetPostArticle(ctx context.Context, arg SetPostArticleParams) error {
+	_, err := q.db.ExecContext(ctx, setPostArticle, arg.ID, arg.Article)
+	return err
+}
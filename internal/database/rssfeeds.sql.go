@@ -7,41 +7,102 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const claimNextFeedToFetch = `-- name: ClaimNextFeedToFetch :one
+WITH due AS (
+    SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_full_article, source_type, source_config
+    FROM rssfeeds
+    WHERE (next_fetch_at IS NULL OR next_fetch_at <= $1::timestamp)
+    -- feeds with an active WebSub subscription are pushed by their hub
+    AND NOT EXISTS (
+        SELECT 1 FROM websub_subscriptions
+        WHERE websub_subscriptions.feed_id = rssfeeds.id
+        AND websub_subscriptions.lease_expires_at > $1::timestamp
+    )
+    ORDER BY last_fetched_at ASC NULLS FIRST LIMIT 1
+    FOR UPDATE SKIP LOCKED
+), claimed AS (
+    UPDATE rssfeeds
+    SET next_fetch_at = $2::timestamp
+    FROM due
+    WHERE rssfeeds.id = due.id
+)
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_full_article, source_type, source_config FROM due
+`
+
+type ClaimNextFeedToFetchParams struct {
+	Now          time.Time
+	ClaimedUntil time.Time
+}
+
+// the due feed is locked so concurrent workers skip it, and its next fetch
+// is pushed to claimed_until so it isn't claimed again while it is fetched.
+// Feeds pushed by a WebSub hub are only polled once their lease is over
+func (q *Queries) ClaimNextFeedToFetch(ctx context.Context, arg ClaimNextFeedToFetchParams) (Rssfeed, error) {
+	row := q.db.QueryRowContext(ctx, claimNextFeedToFetch, arg.Now, arg.ClaimedUntil)
+	var i Rssfeed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchFullArticle,
+		&i.SourceType,
+		&i.SourceConfig,
+	)
+	return i, err
+}
+
 const createRSSFeed = `-- name: CreateRSSFeed :one
-INSERT INTO rssfeeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO rssfeeds (id, created_at, updated_at, name, url, user_id, fetch_full_article, source_type, source_config)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, name, url, user_id
+RETURNING id, created_at, updated_at, name, url, user_id, fetch_full_article, source_type, source_config
 `
 
 type CreateRSSFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Name             string
+	Url              string
+	UserID           uuid.UUID
+	FetchFullArticle bool
+	SourceType       string
+	SourceConfig     json.RawMessage
 }
 
 type CreateRSSFeedRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Name             string
+	Url              string
+	UserID           uuid.UUID
+	FetchFullArticle bool
+	SourceType       string
+	SourceConfig     json.RawMessage
 }
 
 func (q *Queries) CreateRSSFeed(ctx context.Context, arg CreateRSSFeedParams) (CreateRSSFeedRow, error) {
@@ -52,6 +113,9 @@ func (q *Queries) CreateRSSFeed(ctx context.Context, arg CreateRSSFeedParams) (C
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.FetchFullArticle,
+		arg.SourceType,
+		arg.SourceConfig,
 	)
 	var i CreateRSSFeedRow
 	err := row.Scan(
@@ -61,14 +125,47 @@ func (q *Queries) CreateRSSFeed(ctx context.Context, arg CreateRSSFeedParams) (C
 		&i.Name,
 		&i.Url,
 		&i.UserID,
+		&i.FetchFullArticle,
+		&i.SourceType,
+		&i.SourceConfig,
+	)
+	return i, err
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_full_article, source_type, source_config
+FROM rssfeeds
+WHERE rssfeeds.id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Rssfeed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Rssfeed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchFullArticle,
+		&i.SourceType,
+		&i.SourceConfig,
 	)
 	return i, err
 }
 
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_full_article, source_type, source_config
 FROM rssfeeds
 WHERE rssfeeds.Url = $1
+OR rssfeeds.id IN (SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = $1)
+ORDER BY rssfeeds.url = $1 DESC
+LIMIT 1
 `
 
 func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Rssfeed, error) {
@@ -82,6 +179,12 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Rssfeed, error)
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchFullArticle,
+		&i.SourceType,
+		&i.SourceConfig,
 	)
 	return i, err
 }
@@ -124,25 +227,99 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+const getFeedsByName = `-- name: GetFeedsByName :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_full_article, source_type, source_config
 FROM rssfeeds
-ORDER BY last_fetched_at ASC NULLS FIRST LIMIT 1
+WHERE rssfeeds.name = $1
+ORDER BY rssfeeds.created_at
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Rssfeed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Rssfeed
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
-	)
-	return i, err
+func (q *Queries) GetFeedsByName(ctx context.Context, name string) ([]Rssfeed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByName, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rssfeed
+	for rows.Next() {
+		var i Rssfeed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchFullArticle,
+			&i.SourceType,
+			&i.SourceConfig,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowedFeedsByName = `-- name: GetFollowedFeedsByName :many
+SELECT rssfeeds.id, rssfeeds.created_at, rssfeeds.updated_at, rssfeeds.name, rssfeeds.url, rssfeeds.user_id, rssfeeds.last_fetched_at, rssfeeds.etag, rssfeeds.last_modified, rssfeeds.next_fetch_at, rssfeeds.fetch_full_article, rssfeeds.source_type, rssfeeds.source_config
+FROM rssfeeds
+INNER JOIN feed_follows ON feed_follows.feed_id = rssfeeds.id
+WHERE feed_follows.user_id = $1
+AND rssfeeds.name = $2
+ORDER BY rssfeeds.created_at
+`
+
+type GetFollowedFeedsByNameParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) GetFollowedFeedsByName(ctx context.Context, arg GetFollowedFeedsByNameParams) ([]Rssfeed, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsByName, arg.UserID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rssfeed
+	for rows.Next() {
+		var i Rssfeed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchFullArticle,
+			&i.SourceType,
+			&i.SourceConfig,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const markFeedFetched = `-- name: MarkFeedFetched :exec
@@ -156,3 +333,105 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const moveFeed = `-- name: MoveFeed :exec
+WITH old_feed AS (
+    SELECT id, url FROM rssfeeds WHERE rssfeeds.id = $1
+), alias AS (
+    INSERT INTO feed_aliases (url, created_at, feed_id)
+    SELECT old_feed.url, NOW(), old_feed.id FROM old_feed
+    ON CONFLICT (url) DO NOTHING
+), moved_back AS (
+    DELETE FROM feed_aliases WHERE feed_aliases.url = $2
+)
+UPDATE rssfeeds
+SET url = $2,
+updated_at = NOW()
+WHERE rssfeeds.id = $1
+`
+
+type MoveFeedParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) MoveFeed(ctx context.Context, arg MoveFeedParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeed, arg.ID, arg.Url)
+	return err
+}
+
+const renewFeedClaim = `-- name: RenewFeedClaim :execrows
+UPDATE rssfeeds
+SET next_fetch_at = $1::timestamp
+WHERE id = $2
+AND next_fetch_at = $3::timestamp
+`
+
+type RenewFeedClaimParams struct {
+	RenewedUntil time.Time
+	ID           uuid.UUID
+	ClaimedUntil time.Time
+}
+
+// the claim is only extended while the feed is still claimed until
+// claimed_until, once its fetch rescheduled the feed it is left alone
+func (q *Queries) RenewFeedClaim(ctx context.Context, arg RenewFeedClaimParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renewFeedClaim, arg.RenewedUntil, arg.ID, arg.ClaimedUntil)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedFullArticle = `-- name: SetFeedFullArticle :exec
+UPDATE rssfeeds
+SET fetch_full_article = $2,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedFullArticleParams struct {
+	ID               uuid.UUID
+	FetchFullArticle bool
+}
+
+func (q *Queries) SetFeedFullArticle(ctx context.Context, arg SetFeedFullArticleParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFullArticle, arg.ID, arg.FetchFullArticle)
+	return err
+}
+
+const setFeedNextFetch = `-- name: SetFeedNextFetch :exec
+UPDATE rssfeeds
+SET next_fetch_at = $2,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedNextFetchParams struct {
+	ID          uuid.UUID
+	NextFetchAt sql.NullTime
+}
+
+func (q *Queries) SetFeedNextFetch(ctx context.Context, arg SetFeedNextFetchParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetch, arg.ID, arg.NextFetchAt)
+	return err
+}
+
+const updateFeedCache = `-- name: UpdateFeedCache :exec
+UPDATE rssfeeds
+SET etag = $2,
+last_modified = $3,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedCacheParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCache(ctx context.Context, arg UpdateFeedCacheParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCache, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
@@ -0,0 +1,224 @@
+// Package mailbox reads the messages of local Maildir directories and mbox
+// files, such as the newsletters a mail client or fetchmail delivers
+package mailbox
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Message is an email read from a mailbox, with its HTML and plain text
+// bodies when it has them
+type Message struct {
+	// ID is the Message-ID without its angle brackets, it can be empty
+	ID      string
+	From    mail.Address
+	Subject string
+	// Date is zero when the message has no valid Date header
+	Date time.Time
+	HTML string
+	Text string
+}
+
+// Read returns the messages of the Maildir directory or mbox file at path.
+// When from isn't empty only the messages sent by that address are read.
+// Messages larger than maxSize bytes are skipped
+func Read(path, from string, maxSize int64) ([]Message, error) {
+	var messages []Message
+	err := walk(path, maxSize, func(raw []byte) {
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		if err != nil {
+			return
+		}
+		sender := fromAddress(msg.Header)
+		if from != "" && !strings.EqualFold(sender.Address, from) {
+			return
+		}
+		message, err := readMessage(msg, sender)
+		if err != nil {
+			return
+		}
+		messages = append(messages, message)
+	})
+	return messages, err
+}
+
+// Senders returns the addresses that sent the messages at path, once each
+// with the name of their newest message, sorted by address
+func Senders(path string, maxSize int64) ([]mail.Address, error) {
+	type sender struct {
+		address mail.Address
+		date    time.Time
+	}
+	senders := map[string]sender{}
+	err := walk(path, maxSize, func(raw []byte) {
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		if err != nil {
+			return
+		}
+		address := fromAddress(msg.Header)
+		if address.Address == "" {
+			return
+		}
+		date, _ := msg.Header.Date()
+		key := strings.ToLower(address.Address)
+		if known, ok := senders[key]; ok && (known.date.After(date) || address.Name == "") {
+			return
+		}
+		senders[key] = sender{address: address, date: date}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]mail.Address, 0, len(senders))
+	for _, s := range senders {
+		addresses = append(addresses, s.address)
+	}
+	slices.SortFunc(addresses, func(a, b mail.Address) int {
+		return strings.Compare(strings.ToLower(a.Address), strings.ToLower(b.Address))
+	})
+	return addresses, nil
+}
+
+// ModTime returns when the mailbox last changed: the modification time of
+// an mbox file, or the newest of a Maildir and its new and cur directories,
+// which change whenever messages are delivered or moved
+func ModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("couldn't open mailbox: %w", err)
+	}
+	modTime := info.ModTime()
+	if !info.IsDir() {
+		return modTime, nil
+	}
+	for _, dir := range []string{"new", "cur"} {
+		info, err := os.Stat(filepath.Join(path, dir))
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+// walk calls fn with every raw message of the mailbox at path
+func walk(path string, maxSize int64, fn func(raw []byte)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("couldn't open mailbox: %w", err)
+	}
+	if info.IsDir() {
+		return walkMaildir(path, maxSize, fn)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("couldn't open mailbox: %w", err)
+	}
+	defer file.Close()
+	return walkMbox(file, maxSize, fn)
+}
+
+// walkMaildir reads the messages of the new and cur directories of a
+// Maildir, every message is a file of its own
+func walkMaildir(path string, maxSize int64, fn func(raw []byte)) error {
+	found := false
+	for _, dir := range []string{"new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(path, dir))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't read maildir: %w", err)
+		}
+		found = true
+
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.Size() > maxSize {
+				continue
+			}
+			raw, err := os.ReadFile(filepath.Join(path, dir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			fn(raw)
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s is not a maildir, it has no new or cur directory", path)
+	}
+	return nil
+}
+
+// walkMbox splits an mbox file into its messages, each starting with a
+// "From " line. Body lines starting with "From " are escaped as ">From "
+// in the file and are unescaped
+func walkMbox(r io.Reader, maxSize int64, fn func(raw []byte)) error {
+	reader := bufio.NewReader(r)
+	var message bytes.Buffer
+	started := false
+	tooLarge := false
+
+	flush := func() {
+		if started && !tooLarge {
+			fn(bytes.Clone(message.Bytes()))
+		}
+		message.Reset()
+		tooLarge = false
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			switch {
+			case bytes.HasPrefix(line, []byte("From ")):
+				flush()
+				started = true
+			case !started:
+				return errors.New("not an mbox file, it doesn't start with a From line")
+			case !tooLarge:
+				if unescaped, ok := bytes.CutPrefix(line, []byte(">")); ok && bytes.HasPrefix(bytes.TrimLeft(unescaped, ">"), []byte("From ")) {
+					line = unescaped
+				}
+				message.Write(line)
+				if int64(message.Len()) > maxSize {
+					tooLarge = true
+					message.Reset()
+				}
+			}
+		}
+		if err == io.EOF {
+			flush()
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't read mbox: %w", err)
+		}
+	}
+}
+
+// fromAddress returns the first address of the From header
+func fromAddress(header mail.Header) mail.Address {
+	addresses, err := addressParser.ParseList(header.Get("From"))
+	if err != nil || len(addresses) == 0 {
+		return mail.Address{}
+	}
+	return *addresses[0]
+}
@@ -0,0 +1,220 @@
+package mailbox
+
+import (
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testMessages = []string{
+	"From: Swamp Weekly <weekly@swamp.example>\r\n" +
+		"To: gator@example.com\r\n" +
+		"Subject: =?utf-8?q?Gators_=E2=80=93_issue?= 12\r\n" +
+		"Date: Mon, 06 Jan 2025 10:00:00 +0000\r\n" +
+		"Message-ID: <issue-12@swamp.example>\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"alt\"\r\n" +
+		"\r\n" +
+		"--alt\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Gators everywhere.\r\n" +
+		"--alt\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"<p>Gators <b>every=\r\n" +
+		"where</b>.</p>\r\n" +
+		"--alt--\r\n",
+	"From: herons@birds.example\r\n" +
+		"Subject: Heron season\r\n" +
+		"Date: Sun, 05 Jan 2025 08:00:00 +0000\r\n" +
+		"Message-ID: <herons-1@birds.example>\r\n" +
+		"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"SGVyb25zIGluIHRoZSBjYWbpLg==\r\n",
+	"From: weekly@swamp.example\r\n" +
+		"Subject: Gators issue 11\r\n" +
+		"Date: Mon, 30 Dec 2024 10:00:00 +0000\r\n" +
+		"Message-ID: <issue-11@swamp.example>\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"mixed\"\r\n" +
+		"\r\n" +
+		"--mixed\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"From the swamp, issue 11.\r\n" +
+		"--mixed\r\n" +
+		"Content-Type: text/html\r\n" +
+		"Content-Disposition: attachment; filename=\"issue.html\"\r\n" +
+		"\r\n" +
+		"<p>attached</p>\r\n" +
+		"--mixed--\r\n",
+}
+
+var expectedMessages = map[string]Message{
+	"issue-12@swamp.example": {
+		ID:      "issue-12@swamp.example",
+		From:    mail.Address{Name: "Swamp Weekly", Address: "weekly@swamp.example"},
+		Subject: "Gators – issue 12",
+		Date:    time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC),
+		HTML:    "<p>Gators <b>everywhere</b>.</p>",
+		Text:    "Gators everywhere.",
+	},
+	"herons-1@birds.example": {
+		ID:      "herons-1@birds.example",
+		From:    mail.Address{Address: "herons@birds.example"},
+		Subject: "Heron season",
+		Date:    time.Date(2025, 1, 5, 8, 0, 0, 0, time.UTC),
+		Text:    "Herons in the café.",
+	},
+	"issue-11@swamp.example": {
+		ID:      "issue-11@swamp.example",
+		From:    mail.Address{Address: "weekly@swamp.example"},
+		Subject: "Gators issue 11",
+		Date:    time.Date(2024, 12, 30, 10, 0, 0, 0, time.UTC),
+		Text:    "From the swamp, issue 11.",
+	},
+}
+
+// writeMaildir stores the messages as the files of a Maildir
+func writeMaildir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, message := range testMessages {
+		sub := "cur"
+		if i == 0 {
+			sub = "new"
+		}
+		name := filepath.Join(dir, sub, string(rune('a'+i))+".gator:2,S")
+		if err := os.WriteFile(name, []byte(message), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// writeMbox stores the messages in an mbox file, escaping From lines
+func writeMbox(t *testing.T) string {
+	t.Helper()
+	var b strings.Builder
+	for _, message := range testMessages {
+		b.WriteString("From gator@example.com Mon Jan  6 10:00:00 2025\n")
+		message = strings.ReplaceAll(message, "\r\n", "\n")
+		message = strings.ReplaceAll(message, "\nFrom the swamp", "\n>From the swamp")
+		b.WriteString(message)
+		b.WriteString("\n")
+	}
+	path := filepath.Join(t.TempDir(), "newsletters.mbox")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRead(t *testing.T) {
+	mailboxes := map[string]func(t *testing.T) string{
+		"maildir": writeMaildir,
+		"mbox":    writeMbox,
+	}
+
+	for name, write := range mailboxes {
+		t.Run(name, func(t *testing.T) {
+			path := write(t)
+
+			messages, err := Read(path, "", 1<<20)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(messages) != len(expectedMessages) {
+				t.Fatalf("expected %d messages, got %d", len(expectedMessages), len(messages))
+			}
+			for _, got := range messages {
+				expected, ok := expectedMessages[got.ID]
+				if !ok {
+					t.Errorf("unexpected message %+v", got)
+					continue
+				}
+				if got.From != expected.From || got.Subject != expected.Subject || !got.Date.Equal(expected.Date) ||
+					got.HTML != expected.HTML || got.Text != expected.Text {
+					t.Errorf("expected message %+v, got %+v", expected, got)
+				}
+			}
+
+			fromSender, err := Read(path, "WEEKLY@swamp.example", 1<<20)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(fromSender) != 2 {
+				t.Errorf("expected the 2 messages of the sender, got %d", len(fromSender))
+			}
+
+			small, err := Read(path, "", 300)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(small) != 1 || small[0].ID != "herons-1@birds.example" {
+				t.Errorf("expected messages larger than the max size to be skipped, got %+v", small)
+			}
+
+			senders, err := Senders(path, 1<<20)
+			if err != nil {
+				t.Fatalf("Senders() error = %v", err)
+			}
+			expectedSenders := []mail.Address{
+				{Address: "herons@birds.example"},
+				{Name: "Swamp Weekly", Address: "weekly@swamp.example"},
+			}
+			if len(senders) != len(expectedSenders) {
+				t.Fatalf("expected senders %v, got %v", expectedSenders, senders)
+			}
+			for i := range senders {
+				if senders[i] != expectedSenders[i] {
+					t.Errorf("expected sender %v, got %v", expectedSenders[i], senders[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadInvalidMailbox(t *testing.T) {
+	dir := t.TempDir()
+	notMbox := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notMbox, []byte("just some notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{dir, notMbox, filepath.Join(dir, "missing")} {
+		if _, err := Read(path, "", 1<<20); err == nil {
+			t.Errorf("expected an error reading %s", path)
+		}
+	}
+}
+
+func TestModTime(t *testing.T) {
+	dir := writeMaildir(t)
+	before, err := ModTime(dir)
+	if err != nil {
+		t.Fatalf("ModTime() error = %v", err)
+	}
+
+	later := before.Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "new"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	after, err := ModTime(dir)
+	if err != nil {
+		t.Fatalf("ModTime() error = %v", err)
+	}
+	if !after.Equal(later) {
+		t.Errorf("expected a delivery to change the mod time to %v, got %v", later, after)
+	}
+}
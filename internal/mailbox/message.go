@@ -0,0 +1,123 @@
+package mailbox
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// maxDepth bounds how deep multipart bodies are read
+const maxDepth = 10
+
+// wordDecoder decodes headers such as =?iso-8859-1?q?Caf=E9?= in any
+// charset known to the html package, not only UTF-8 and Latin-1
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: charset.NewReaderLabel,
+}
+
+var addressParser = &mail.AddressParser{WordDecoder: wordDecoder}
+
+// readMessage reads the subject, date and bodies of a message sent by from
+func readMessage(msg *mail.Message, from mail.Address) (Message, error) {
+	message := Message{
+		ID:   strings.Trim(msg.Header.Get("Message-Id"), "<> \t"),
+		From: from,
+	}
+	message.Subject = msg.Header.Get("Subject")
+	if subject, err := wordDecoder.DecodeHeader(message.Subject); err == nil {
+		message.Subject = subject
+	}
+	message.Subject = strings.Join(strings.Fields(message.Subject), " ")
+	if date, err := msg.Header.Date(); err == nil {
+		message.Date = date
+	}
+
+	if err := readPart(textproto.MIMEHeader(msg.Header), msg.Body, &message, 0); err != nil {
+		return Message{}, fmt.Errorf("couldn't read body of message: %w", err)
+	}
+	return message, nil
+}
+
+// readPart keeps the first HTML and plain text bodies found in the part,
+// looking inside multipart parts and skipping attachments
+func readPart(header textproto.MIMEHeader, body io.Reader, message *Message, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// parts without a valid type are plain text
+		mediaType, params = "text/plain", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxDepth || params["boundary"] == "" {
+			return nil
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := readPart(part.Header, part, message, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition")); disposition == "attachment" {
+		return nil
+	}
+	var dest *string
+	switch mediaType {
+	case "text/html":
+		dest = &message.HTML
+	case "text/plain":
+		dest = &message.Text
+	default:
+		return nil
+	}
+	if *dest != "" {
+		return nil
+	}
+
+	text, err := decodeText(body, header.Get("Content-Transfer-Encoding"), params["charset"])
+	if err != nil {
+		return err
+	}
+	*dest = strings.TrimSpace(text)
+	return nil
+}
+
+// decodeText undoes the transfer encoding of the body and converts it from
+// its charset to UTF-8
+func decodeText(body io.Reader, encoding, label string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	if label != "" && !strings.EqualFold(label, "utf-8") && !strings.EqualFold(label, "us-ascii") {
+		// bodies in unknown charsets are kept as they are
+		if reader, err := charset.NewReaderLabel(label, body); err == nil {
+			body = reader
+		}
+	}
+
+	text, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
@@ -0,0 +1,197 @@
+// Package pubdate parses the publication dates found in feeds, which use
+// RFC 822, RFC 3339 and a long tail of hand written variations of both
+package pubdate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUnparseable is returned when the date doesn't match any known layout
+var ErrUnparseable = errors.New("unrecognized date format")
+
+// Date is a parsed publication date
+type Date struct {
+	// Time is normalized to UTC
+	Time time.Time
+	// Guessed is set when the date didn't include a known time zone or a
+	// time of day, which were assumed to be UTC and midnight
+	Guessed bool
+}
+
+type layout struct {
+	format string
+	// complete is false for layouts without a time zone or a time of day
+	complete bool
+}
+
+// layouts are tried in order on the normalized date, which has no weekday,
+// single spaces and numeric time zones
+var layouts = []layout{
+	// RFC 822 / RFC 1123 and variations
+	{"2 Jan 2006 15:04:05 -0700", true},
+	{"2 Jan 2006 15:04:05 -07:00", true},
+	{"2 Jan 2006 15:04 -0700", true},
+	{"2 Jan 2006 15:04 -07:00", true},
+	{"2 Jan 06 15:04:05 -0700", true},
+	{"2 Jan 06 15:04 -0700", true},
+	{"2 January 2006 15:04:05 -0700", true},
+	{"2 January 2006 15:04 -0700", true},
+	{"2 Jan 2006 15:04:05", false},
+	{"2 Jan 2006 15:04", false},
+	{"2 January 2006 15:04:05", false},
+	{"2 January 2006 15:04", false},
+	{"2 Jan 2006", false},
+	{"2 January 2006", false},
+
+	// month first
+	{"Jan 2 2006 15:04:05 -0700", true},
+	{"Jan 2, 2006 15:04:05 -0700", true},
+	{"January 2, 2006 15:04:05 -0700", true},
+	{"Jan 2, 2006 3:04 PM -0700", true},
+	{"January 2, 2006 3:04 PM -0700", true},
+	{"Jan 2 15:04:05 -0700 2006", true},
+	{"Jan 2 15:04:05 2006", false},
+	{"Jan 2, 2006 15:04:05", false},
+	{"Jan 2, 2006 3:04 PM", false},
+	{"January 2, 2006 3:04 PM", false},
+	{"Jan 2, 2006", false},
+	{"January 2, 2006", false},
+	{"Jan 2 2006", false},
+
+	// RFC 3339 / ISO 8601 and variations
+	{time.RFC3339Nano, true},
+	{"2006-01-02T15:04Z07:00", true},
+	{"2006-01-02T15:04:05.999999999-0700", true},
+	{"2006-01-02T15:04-0700", true},
+	{"2006-01-02 15:04:05.999999999Z07:00", true},
+	{"2006-01-02 15:04Z07:00", true},
+	{"2006-01-02 15:04:05.999999999 -0700", true},
+	{"2006-01-02 15:04:05.999999999 -07:00", true},
+	{"2006-01-02 15:04 -0700", true},
+	{"2006-01-02T15:04:05.999999999", false},
+	{"2006-01-02T15:04", false},
+	{"2006-01-02 15:04:05.999999999", false},
+	{"2006-01-02 15:04", false},
+	{"2006-01-02", false},
+	{"2006/01/02 15:04:05 -0700", true},
+	{"2006/01/02 15:04:05", false},
+	{"2006/01/02", false},
+}
+
+// Parse parses a feed date. Weekday names, repeated spaces and trailing
+// comments are ignored, named time zones are converted to their offset and
+// unknown ones are dropped. The result is in UTC
+func Parse(s string) (Date, error) {
+	normalized, zoneKnown := normalize(s)
+	if normalized == "" {
+		return Date{}, fmt.Errorf("%w: empty date", ErrUnparseable)
+	}
+
+	for _, l := range layouts {
+		t, err := time.Parse(l.format, normalized)
+		if err != nil {
+			continue
+		}
+		return Date{
+			Time:    t.UTC(),
+			Guessed: !l.complete || !zoneKnown,
+		}, nil
+	}
+	return Date{}, fmt.Errorf("%w: %q", ErrUnparseable, s)
+}
+
+// normalize rewrites the date into the shape the layouts expect. zoneKnown is
+// false when a named time zone that isn't in the zones table was removed
+func normalize(s string) (normalized string, zoneKnown bool) {
+	zoneKnown = true
+	fields := strings.Fields(s)
+
+	// trailing comments such as "-0700 (PDT)"
+	for len(fields) > 1 && strings.HasPrefix(fields[len(fields)-1], "(") && strings.HasSuffix(fields[len(fields)-1], ")") {
+		fields = fields[:len(fields)-1]
+	}
+
+	// weekdays, in any language, are written before the day and end in a comma
+	if len(fields) > 1 && strings.HasSuffix(fields[0], ",") && !startsWithDigit(fields[0]) && !isMonth(strings.TrimSuffix(fields[0], ",")) {
+		fields = fields[1:]
+	}
+	if len(fields) > 1 && isWeekday(fields[0]) {
+		fields = fields[1:]
+	}
+	// a comma glued to the day, "06,May"
+	if len(fields) > 0 {
+		if before, after, found := strings.Cut(fields[0], ","); found && isWeekday(before) && after != "" {
+			fields[0] = after
+		}
+	}
+
+	for i, field := range fields {
+		if name, ok := monthAliases[strings.ToLower(field)]; ok {
+			fields[i] = name
+		}
+	}
+
+	if len(fields) > 1 {
+		last := fields[len(fields)-1]
+		if offset, ok := zoneOffset(last); ok {
+			fields[len(fields)-1] = offset
+		} else if isAlpha(last) && !isMeridiem(last) && !isMonth(last) {
+			fields = fields[:len(fields)-1]
+			zoneKnown = false
+		}
+	}
+
+	return strings.Join(fields, " "), zoneKnown
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+func isAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isMeridiem(s string) bool {
+	s = strings.ToUpper(s)
+	return s == "AM" || s == "PM"
+}
+
+func isWeekday(s string) bool {
+	s = strings.ToLower(strings.TrimSuffix(s, ","))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if s == name || s == name[:3] {
+			return true
+		}
+	}
+	return false
+}
+
+func isMonth(s string) bool {
+	s = strings.ToLower(strings.TrimSuffix(s, ","))
+	for month := time.January; month <= time.December; month++ {
+		name := strings.ToLower(month.String())
+		if s == name || s == name[:3] {
+			return true
+		}
+	}
+	return false
+}
+
+// month spellings time.Parse doesn't accept
+var monthAliases = map[string]string{
+	"sept":  "Sep",
+	"sept.": "Sep",
+}
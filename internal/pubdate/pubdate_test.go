@@ -0,0 +1,234 @@
+package pubdate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expected        time.Time
+		expectedGuessed bool
+		expectError     bool
+	}{
+		{
+			name:     "RFC 1123 with offset",
+			input:    "Mon, 06 May 2024 10:00:00 +0000",
+			expected: time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "RFC 1123 with GMT",
+			input:    "Mon, 06 May 2024 10:00:00 GMT",
+			expected: time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "named zone is converted to UTC",
+			input:    "Mon, 06 May 2024 10:00:00 EDT",
+			expected: time.Date(2024, 5, 6, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "pacific standard time",
+			input:    "Tue, 9 Jan 2024 08:15:00 PST",
+			expected: time.Date(2024, 1, 9, 16, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "half hour named zone",
+			input:    "Mon, 06 May 2024 10:00:00 IST",
+			expected: time.Date(2024, 5, 6, 4, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "single digit day",
+			input:    "Mon, 6 May 2024 10:00:00 +0200",
+			expected: time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "extra whitespace",
+			input:    "  Mon,  06   May 2024\n\t10:00:00  +0000 ",
+			expected: time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekday in another locale",
+			input:    "Lun, 06 May 2024 10:00:00 +0200",
+			expected: time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "long weekday in another locale",
+			input:    "Mittwoch, 8 May 2024 09:30:00 +0200",
+			expected: time.Date(2024, 5, 8, 7, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekday without comma",
+			input:    "Monday 06 May 2024 10:00 +0000",
+			expected: time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "no seconds",
+			input:    "Mon, 06 May 2024 10:00 CEST",
+			expected: time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "two digit year",
+			input:    "06 May 24 10:00 -0500",
+			expected: time.Date(2024, 5, 6, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "full month name",
+			input:    "6 September 2024 10:00:00 +0000",
+			expected: time.Date(2024, 9, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "sept",
+			input:    "Fri, 6 Sept 2024 10:00:00 +0000",
+			expected: time.Date(2024, 9, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "trailing zone comment",
+			input:    "Mon, 06 May 2024 10:00:00 -0700 (PDT)",
+			expected: time.Date(2024, 5, 6, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "offset with colon",
+			input:    "Mon, 06 May 2024 10:00:00 +05:30",
+			expected: time.Date(2024, 5, 6, 4, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "month first with meridiem",
+			input:    "May 6, 2024 3:04 PM -0400",
+			expected: time.Date(2024, 5, 6, 19, 4, 0, 0, time.UTC),
+		},
+		{
+			name:     "RFC 3339",
+			input:    "2024-05-06T10:00:00-05:00",
+			expected: time.Date(2024, 5, 6, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "RFC 3339 with fraction",
+			input:    "2024-05-06T10:00:00.250Z",
+			expected: time.Date(2024, 5, 6, 10, 0, 0, 250000000, time.UTC),
+		},
+		{
+			name:     "ISO without seconds",
+			input:    "2024-05-06T10:00Z",
+			expected: time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "ISO with numeric offset without colon",
+			input:    "2024-05-06T10:00:00+0200",
+			expected: time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "space separated ISO with named zone",
+			input:    "2024-05-06 10:00:00 EST",
+			expected: time.Date(2024, 5, 6, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "ISO without zone is guessed",
+			input:           "2024-05-06T10:00:00",
+			expected:        time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+			expectedGuessed: true,
+		},
+		{
+			name:            "date only is guessed",
+			input:           "2024-05-06",
+			expected:        time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+			expectedGuessed: true,
+		},
+		{
+			name:            "month first date only is guessed",
+			input:           "May 6, 2024",
+			expected:        time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+			expectedGuessed: true,
+		},
+		{
+			name:            "unknown zone is guessed",
+			input:           "Mon, 06 May 2024 10:00:00 XYZT",
+			expected:        time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+			expectedGuessed: true,
+		},
+		{
+			name:        "empty",
+			input:       "   ",
+			expectError: true,
+		},
+		{
+			name:        "not a date",
+			input:       "yesterday",
+			expectError: true,
+		},
+		{
+			name:        "invalid day",
+			input:       "Mon, 32 May 2024 10:00:00 +0000",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.expectError {
+				if !errors.Is(err, ErrUnparseable) {
+					t.Errorf("expected ErrUnparseable, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+
+			if !got.Time.Equal(tt.expected) || got.Time.Location() != time.UTC {
+				t.Errorf("expected %v, got %v", tt.expected, got.Time)
+			}
+			if got.Guessed != tt.expectedGuessed {
+				t.Errorf("expected guessed = %v, got %v", tt.expectedGuessed, got.Guessed)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"Mon, 06 May 2024 10:00:00 +0000",
+		"Mon, 06 May 2024 10:00:00 EDT",
+		"Lun, 6 May 2024 10:00 (CEST)",
+		"May 6, 2024 3:04 PM",
+		"2024-05-06T10:00:00.250-05:00",
+		"2024-05-06",
+		"",
+		",",
+		"Mon,",
+		"( )",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		got, err := Parse(input)
+		if err != nil {
+			if !errors.Is(err, ErrUnparseable) {
+				t.Fatalf("expected ErrUnparseable, got %v", err)
+			}
+			return
+		}
+
+		if got.Time.Location() != time.UTC {
+			t.Fatalf("Parse(%q) returned a time in %v", input, got.Time.Location())
+		}
+
+		// a parsed date written back as RFC 3339 is the same complete date,
+		// RFC 3339 has no room for years outside of 0-9999
+		if got.Time.Year() < 0 || got.Time.Year() > 9999 {
+			return
+		}
+		again, err := Parse(got.Time.Format(time.RFC3339Nano))
+		if err != nil {
+			t.Fatalf("Parse(%q) gave %v, which doesn't parse back: %v", input, got.Time, err)
+		}
+		if !again.Time.Equal(got.Time) || again.Guessed {
+			t.Fatalf("Parse(%q) gave %v, which parses back as %+v", input, got.Time, again)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package pubdate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// zones maps the time zone names found in feeds to their UTC offset in
+// minutes. Names used for several zones, like IST, map to the most common one
+var zones = map[string]int{
+	// RFC 822
+	"UT":  0,
+	"UTC": 0,
+	"GMT": 0,
+	"Z":   0,
+	"EST": -5 * 60,
+	"EDT": -4 * 60,
+	"CST": -6 * 60,
+	"CDT": -5 * 60,
+	"MST": -7 * 60,
+	"MDT": -6 * 60,
+	"PST": -8 * 60,
+	"PDT": -7 * 60,
+
+	// North America
+	"AKST": -9 * 60,
+	"AKDT": -8 * 60,
+	"HST":  -10 * 60,
+	"AST":  -4 * 60,
+	"ADT":  -3 * 60,
+	"NST":  -(3*60 + 30),
+	"NDT":  -(2*60 + 30),
+
+	// Europe
+	"WET":  0,
+	"WEST": 60,
+	"BST":  60,
+	"IST":  5*60 + 30,
+	"CET":  60,
+	"CEST": 2 * 60,
+	"MET":  60,
+	"MEST": 2 * 60,
+	"EET":  2 * 60,
+	"EEST": 3 * 60,
+	"MSK":  3 * 60,
+
+	// Asia and Oceania
+	"PKT":  5 * 60,
+	"ICT":  7 * 60,
+	"WIB":  7 * 60,
+	"SGT":  8 * 60,
+	"HKT":  8 * 60,
+	"AWST": 8 * 60,
+	"JST":  9 * 60,
+	"KST":  9 * 60,
+	"ACST": 9*60 + 30,
+	"ACDT": 10*60 + 30,
+	"AEST": 10 * 60,
+	"AEDT": 11 * 60,
+	"NZST": 12 * 60,
+	"NZDT": 13 * 60,
+}
+
+// zoneOffset converts a named time zone into a numeric offset such as "-0400"
+func zoneOffset(name string) (string, bool) {
+	minutes, ok := zones[strings.ToUpper(name)]
+	if !ok {
+		return "", false
+	}
+	sign := '+'
+	if minutes < 0 {
+		sign = '-'
+		minutes = -minutes
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, minutes/60, minutes%60), true
+}
@@ -0,0 +1,373 @@
+// Package readability extracts the main text of an article page, leaving out
+// the navigation, sidebars, comments and ads around it
+package readability
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var ErrNoArticle = errors.New("no article found in page")
+
+// Article is the main content of a page
+type Article struct {
+	Title string
+	// Text is the content as plain text, with paragraphs separated by blank lines
+	Text string
+}
+
+const (
+	// pages with less text than this have no article, only teasers or links
+	minTextLength = 200
+	// shorter paragraphs are captions, bylines or buttons and aren't scored
+	minParagraphLength = 25
+)
+
+var (
+	// unlikelyNames are classes and ids of the parts of a page around the
+	// article, removed unless they also look like content
+	unlikelyNames = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|menu|modal|nav|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tags|tool|widget`)
+	maybeNames    = regexp.MustCompile(`(?i)article|body|column|content|main|shadow`)
+	positiveNames = regexp.MustCompile(`(?i)article|blog|body|content|entry|h-entry|hentry|main|page|post|story|text`)
+	negativeNames = regexp.MustCompile(`(?i)-ad-|banner|combx|comment|com-|contact|footer|footnote|gdpr|hidden|masthead|meta|outbrain|promo|related|scroll|share|shopping|shoutbox|sidebar|skyscraper|sponsor|tags|tool|widget`)
+)
+
+// removedElements never hold article text
+var removedElements = map[atom.Atom]bool{
+	atom.Aside:    true,
+	atom.Button:   true,
+	atom.Embed:    true,
+	atom.Footer:   true,
+	atom.Form:     true,
+	atom.Header:   true,
+	atom.Iframe:   true,
+	atom.Input:    true,
+	atom.Nav:      true,
+	atom.Noscript: true,
+	atom.Object:   true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Textarea: true,
+}
+
+// blockElements start a new paragraph in the text and keep a div from
+// being scored as a paragraph itself
+var blockElements = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Blockquote: true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Hr:         true,
+	atom.Li:         true,
+	atom.Main:       true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Tr:         true,
+	atom.Ul:         true,
+}
+
+// Extract parses the HTML page and returns its title and the text of its
+// main content. Paragraphs are scored on their length and commas, the
+// scores go to their parents, and the element with the best score, less
+// its share of link text, is taken with the siblings that look like part
+// of it. Pages without enough text return ErrNoArticle
+func Extract(r io.Reader) (Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return Article{}, fmt.Errorf("couldn't parse page: %w", err)
+	}
+
+	article := Article{Title: pageTitle(doc)}
+	prune(doc)
+
+	scores := scoreParagraphs(doc)
+	top := topCandidate(scores)
+	if top == nil {
+		return article, ErrNoArticle
+	}
+
+	var w textWriter
+	// text outside links, lists of links to other pages aren't an article
+	textLength := 0.0
+	for _, node := range articleNodes(top, scores) {
+		w.writeNode(node, false)
+		textLength += float64(utf8.RuneCountInString(innerText(node))) * (1 - linkDensity(node))
+	}
+	if textLength < minTextLength {
+		return article, ErrNoArticle
+	}
+	article.Text = w.String()
+	return article, nil
+}
+
+// pageTitle returns the og:title of the page, its <title> or its first <h1>
+func pageTitle(doc *html.Node) string {
+	var ogTitle, title, h1 string
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.DataAtom {
+		case atom.Meta:
+			if attr(n, "property") == "og:title" && ogTitle == "" {
+				ogTitle = collapseSpaces(attr(n, "content"))
+			}
+		case atom.Title:
+			if title == "" {
+				title = innerText(n)
+			}
+		case atom.H1:
+			if h1 == "" {
+				h1 = innerText(n)
+			}
+		}
+	}
+	for _, candidate := range []string{ogTitle, title, h1} {
+		if candidate != "" {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// prune removes the elements that can't be part of the article
+func prune(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.CommentNode:
+			n.RemoveChild(child)
+		case child.Type == html.ElementNode && unlikely(child):
+			n.RemoveChild(child)
+		default:
+			prune(child)
+		}
+		child = next
+	}
+}
+
+func unlikely(n *html.Node) bool {
+	if removedElements[n.DataAtom] {
+		return true
+	}
+	if _, hidden := attrValue(n, "hidden"); hidden || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+		return true
+	}
+
+	switch n.DataAtom {
+	case atom.Html, atom.Body, atom.Article, atom.Main:
+		return false
+	}
+	names := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyNames.MatchString(names) && !maybeNames.MatchString(names)
+}
+
+// scoreParagraphs gives every paragraph's parent its score and its
+// grandparent half of it
+func scoreParagraphs(doc *html.Node) map[*html.Node]float64 {
+	scores := map[*html.Node]float64{}
+	for n := range doc.Descendants() {
+		if !isParagraph(n) {
+			continue
+		}
+		text := innerText(n)
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLength {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + float64(min(length/100, 3))
+
+		ancestor := n.Parent
+		for level := 0; level < 2 && ancestor != nil && ancestor.Type == html.ElementNode; level++ {
+			if _, ok := scores[ancestor]; !ok {
+				scores[ancestor] = initialScore(ancestor)
+			}
+			scores[ancestor] += score / float64(level+1)
+			ancestor = ancestor.Parent
+		}
+	}
+	return scores
+}
+
+// isParagraph reports whether the element holds text of its own: paragraphs,
+// preformatted text, quotes, cells and divs used as paragraphs
+func isParagraph(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		return true
+	case atom.Div, atom.Section:
+		for child := range n.Descendants() {
+			if child != n && child.Type == html.ElementNode && blockElements[child.DataAtom] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// initialScore weighs the element on its tag, its class and its id
+func initialScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		score += 10
+	case atom.Div:
+		score += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score += 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+	return score + classWeight(n)
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, name := range []string{attr(n, "class"), attr(n, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeNames.MatchString(name) {
+			weight -= 25
+		}
+		if positiveNames.MatchString(name) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// topCandidate returns the scored element with the best score once the
+// share of its text inside links is taken out
+func topCandidate(scores map[*html.Node]float64) *html.Node {
+	var top *html.Node
+	topScore := 0.0
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		scores[n] = score
+		if top == nil || score > topScore {
+			top, topScore = n, score
+		}
+	}
+	return top
+}
+
+// articleNodes returns the top candidate and its siblings that belong to the
+// article, such as paragraphs split from the main content by an image
+func articleNodes(top *html.Node, scores map[*html.Node]float64) []*html.Node {
+	if top.Parent == nil {
+		return []*html.Node{top}
+	}
+
+	topScore := scores[top]
+	threshold := max(10, topScore*0.2)
+	topClass := attr(top, "class")
+
+	var nodes []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling == top {
+			nodes = append(nodes, sibling)
+			continue
+		}
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+
+		bonus := 0.0
+		if topClass != "" && attr(sibling, "class") == topClass {
+			bonus = topScore * 0.2
+		}
+		if score, ok := scores[sibling]; ok && score+bonus >= threshold {
+			nodes = append(nodes, sibling)
+			continue
+		}
+		if sibling.DataAtom == atom.P {
+			text := innerText(sibling)
+			length := utf8.RuneCountInString(text)
+			density := linkDensity(sibling)
+			if (length > 80 && density < 0.25) || (length > 0 && density == 0 && strings.Contains(text, ". ")) {
+				nodes = append(nodes, sibling)
+			}
+		}
+	}
+	return nodes
+}
+
+// linkDensity is the share of the text of the element inside links
+func linkDensity(n *html.Node) float64 {
+	length := utf8.RuneCountInString(innerText(n))
+	if length == 0 {
+		return 0
+	}
+	linkLength := 0
+	for child := range n.Descendants() {
+		if child.Type == html.ElementNode && child.DataAtom == atom.A {
+			linkLength += utf8.RuneCountInString(innerText(child))
+		}
+	}
+	return min(float64(linkLength)/float64(length), 1)
+}
+
+// innerText returns the text inside the element with its spaces collapsed
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	for child := range n.Descendants() {
+		if child.Type == html.TextNode {
+			b.WriteString(child.Data)
+			b.WriteString(" ")
+		}
+	}
+	return collapseSpaces(b.String())
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	value, _ := attrValue(n, key)
+	return value
+}
+
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,125 @@
+package readability
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	page, err := os.Open(filepath.Join("testdata", "article.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer page.Close()
+
+	article, err := Extract(page)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if article.Title != "Why gators bask" {
+		t.Errorf("expected the og:title, got %q", article.Title)
+	}
+
+	expected := `Alligators are ectotherms, which means they rely on the sun, the water and the mud around them to control their body temperature.
+
+On cool mornings they haul out on the banks, spread their legs and open their mouths, soaking up heat until their blood is warm enough to digest a meal, chase prey or defend a nest.
+
+A gator basking on a log.
+
+When the day gets too hot, they slide back into the water, where the temperature changes slowly, and wait there for the evening.
+
+What to look for
+
+- Open mouths
+- Raised tails
+
+gator  =  cold blooded
+sun    -> warm gator`
+	if article.Text != expected {
+		t.Errorf("expected text:\n%s\n\ngot:\n%s", expected, article.Text)
+	}
+
+	for _, unwanted := range []string{"Popular posts", "Share this post", "Great post", "Copyright", "Archive", "analytics"} {
+		if strings.Contains(article.Text, unwanted) {
+			t.Errorf("expected %q to be left out of the article", unwanted)
+		}
+	}
+}
+
+func TestExtract_NoArticle(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+	}{
+		{
+			name: "list of links",
+			page: readFile(t, "links.html"),
+		},
+		{
+			name: "teaser only",
+			page: `<html><head><title>Short</title></head><body><p>Read the rest of this post on our website.</p></body></html>`,
+		},
+		{
+			name: "empty page",
+			page: ``,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Extract(strings.NewReader(tt.page))
+			if !errors.Is(err, ErrNoArticle) {
+				t.Errorf("expected ErrNoArticle, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExtract_TextFormatting(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "div used as paragraph",
+			body:     `<div class="content">` + strings.Repeat("Some text in a div, ", 12) + `<br>after a <b>line</b>   break.</div>`,
+			expected: strings.TrimSpace(strings.Repeat("Some text in a div, ", 12)) + "\nafter a line break.",
+		},
+		{
+			name:     "hidden elements",
+			body:     `<main><p>` + strings.Repeat("Visible text, ", 16) + `</p><p style="display: none">Hidden text, hidden text, hidden text, hidden text.</p><p hidden>Hidden too</p></main>`,
+			expected: strings.TrimSpace(strings.Repeat("Visible text, ", 16)),
+		},
+		{
+			name:     "table cells",
+			body:     `<div class="post"><p>` + strings.Repeat("Before the table, ", 12) + `</p><table><tr><th>Name</th><th>Length</th></tr><tr><td>Gator</td><td>4m</td></tr></table></div>`,
+			expected: strings.TrimSpace(strings.Repeat("Before the table, ", 12)) + "\n\nName Length\nGator 4m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article, err := Extract(strings.NewReader("<html><body>" + tt.body + "</body></html>"))
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if article.Text != tt.expected {
+				t.Errorf("expected text:\n%q\ngot:\n%q", tt.expected, article.Text)
+			}
+		})
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
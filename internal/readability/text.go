@@ -0,0 +1,117 @@
+package readability
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// textWriter renders elements as plain text, block elements are separated
+// by blank lines and spaces are collapsed outside of <pre>
+type textWriter struct {
+	b strings.Builder
+	// newlines owed before the next text
+	pending int
+	// whether the last character written is a space or a newline
+	spaced bool
+}
+
+func (w *textWriter) String() string {
+	lines := strings.Split(w.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// lineBreak asks for n newlines before the next text, none at the start
+func (w *textWriter) lineBreak(n int) {
+	if w.b.Len() > 0 {
+		w.pending = max(w.pending, n)
+	}
+}
+
+func (w *textWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if w.pending > 0 {
+		w.b.WriteString(strings.Repeat("\n", w.pending))
+		w.pending = 0
+		w.spaced = true
+	}
+	w.b.WriteString(s)
+	last := s[len(s)-1]
+	w.spaced = last == ' ' || last == '\n'
+}
+
+func (w *textWriter) writeNode(n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			w.write(n.Data)
+			return
+		}
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if text == "" {
+			if n.Data != "" && !w.spaced && w.pending == 0 {
+				w.write(" ")
+			}
+			return
+		}
+		if isSpace(n.Data[0]) && !w.spaced && w.pending == 0 {
+			text = " " + text
+		}
+		if isSpace(n.Data[len(n.Data)-1]) {
+			text += " "
+		}
+		w.write(text)
+		return
+	case html.ElementNode:
+	default:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			w.writeNode(child, pre)
+		}
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.lineBreak(1)
+		return
+	case atom.Img, atom.Picture, atom.Video, atom.Audio, atom.Canvas:
+		return
+	case atom.Li:
+		w.lineBreak(1)
+		w.write("- ")
+	case atom.Tr:
+		w.lineBreak(1)
+	case atom.Td, atom.Th:
+		if !w.spaced && w.pending == 0 {
+			w.write(" ")
+		}
+	default:
+		if blockElements[n.DataAtom] {
+			w.lineBreak(2)
+		}
+	}
+
+	pre = pre || n.DataAtom == atom.Pre
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.writeNode(child, pre)
+	}
+
+	switch n.DataAtom {
+	case atom.Li, atom.Tr:
+		w.lineBreak(1)
+	default:
+		if blockElements[n.DataAtom] {
+			w.lineBreak(2)
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,109 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Adapter builds the Source of a feed from its url and its config, the JSON
+// stored with the feed, which is empty for feeds that need none. Adapters
+// let gator read sources that aren't feeds, such as pages and JSON APIs,
+// into the same items as RSS
+type Adapter func(c *Client, feedURL string, config json.RawMessage) (Source, error)
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = map[string]Adapter{}
+)
+
+func init() {
+	RegisterAdapter("http", httpAdapter)
+	RegisterAdapter("https", httpAdapter)
+	RegisterAdapter("file", fileAdapter)
+	RegisterAdapter("scrape", scrapeAdapter)
+	RegisterAdapter("json", jsonAdapter)
+	RegisterAdapter("mail", mailAdapter)
+}
+
+// RegisterAdapter makes the adapter available under name, either a url
+// scheme used by feeds without a source type or a source type stored with
+// the feed. Registering a name twice panics
+func RegisterAdapter(name string, adapter Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+
+	name = strings.ToLower(name)
+	if adapter == nil {
+		panic("rss: RegisterAdapter adapter is nil")
+	}
+	if _, dup := adapters[name]; dup {
+		panic("rss: RegisterAdapter called twice for " + name)
+	}
+	adapters[name] = adapter
+}
+
+// Adapters returns the names of the registered adapters, sorted
+func Adapters() []string {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+
+	names := make([]string, 0, len(adapters))
+	for name := range adapters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func lookupAdapter(name string) (Adapter, bool) {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	adapter, ok := adapters[strings.ToLower(name)]
+	return adapter, ok
+}
+
+// NewTypedSource calls NewTypedSource on DefaultClient
+func NewTypedSource(sourceType, feedURL string, config json.RawMessage) (Source, error) {
+	return DefaultClient.NewTypedSource(sourceType, feedURL, config)
+}
+
+// NewTypedSource returns the source built by the adapter registered as
+// sourceType, or by the adapter of the url scheme when sourceType is empty
+func (c *Client) NewTypedSource(sourceType, feedURL string, config json.RawMessage) (Source, error) {
+	if sourceType != "" {
+		adapter, ok := lookupAdapter(sourceType)
+		if !ok {
+			return nil, fmt.Errorf("unknown source type %q", sourceType)
+		}
+		return adapter(c, feedURL, config)
+	}
+
+	parsed, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed url %q: %w", feedURL, err)
+	}
+	adapter, ok := lookupAdapter(parsed.Scheme)
+	if !ok || parsed.Scheme == "" {
+		return nil, fmt.Errorf("unsupported feed url scheme %q", parsed.Scheme)
+	}
+	return adapter(c, feedURL, config)
+}
+
+// decodeConfig decodes the config of an adapter, rejecting unknown fields so
+// typos in paths and selectors are reported when the feed is added
+func decodeConfig(config json.RawMessage, v any) error {
+	if len(config) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(config))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid source config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package rss
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewTypedSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		sourceType  string
+		feedURL     string
+		config      string
+		expected    Source
+		expectError bool
+	}{
+		{
+			name:     "type from the url scheme",
+			feedURL:  "https://example.com/feed.xml",
+			expected: HTTPSource{URL: "https://example.com/feed.xml", Client: DefaultClient},
+		},
+		{
+			name:       "scraped page",
+			sourceType: "scrape",
+			feedURL:    "https://example.com/news",
+			config:     `{"item": ".story", "title": "h2"}`,
+			expected:   ScrapeSource{URL: "https://example.com/news", Selectors: Selectors{Item: ".story", Title: "h2"}, Client: DefaultClient},
+		},
+		{
+			name:       "json api",
+			sourceType: "JSON",
+			feedURL:    "https://api.example.com/search",
+			config:     `{"items": "hits", "title": "title", "link": "url"}`,
+			expected:   JSONSource{URL: "https://api.example.com/search", Mapping: JSONMapping{Items: "hits", Title: "title", Link: "url"}, Client: DefaultClient},
+		},
+		{
+			name:       "newsletters of a sender",
+			sourceType: "mail",
+			feedURL:    "mailto:news@example.com",
+			config:     `{"path": "/home/gator/Maildir"}`,
+			expected:   MailSource{Path: "/home/gator/Maildir", From: "news@example.com"},
+		},
+		{
+			name:       "whole mailbox",
+			sourceType: "mail",
+			feedURL:    "file:///var/mail/gator",
+			expected:   MailSource{Path: "/var/mail/gator"},
+		},
+		{
+			name:        "sender without mailbox",
+			sourceType:  "mail",
+			feedURL:     "mailto:news@example.com",
+			expectError: true,
+		},
+		{
+			name:        "unknown type",
+			sourceType:  "gopher",
+			feedURL:     "https://example.com/",
+			expectError: true,
+		},
+		{
+			name:        "unknown config field",
+			sourceType:  "json",
+			feedURL:     "https://api.example.com/search",
+			config:      `{"items": "hits", "titel": "title"}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid selectors",
+			sourceType:  "scrape",
+			feedURL:     "https://example.com/news",
+			config:      `{"item": ".story"}`,
+			expectError: true,
+		},
+		{
+			name:        "url without scheme",
+			feedURL:     "example.com/feed.xml",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config json.RawMessage
+			if tt.config != "" {
+				config = json.RawMessage(tt.config)
+			}
+			got, err := NewTypedSource(tt.sourceType, tt.feedURL, config)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTypedSource() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %#v, got %#v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRegisterAdapter(t *testing.T) {
+	called := false
+	RegisterAdapter("test-adapter", func(c *Client, feedURL string, config json.RawMessage) (Source, error) {
+		called = true
+		return FileSource{Path: feedURL}, nil
+	})
+
+	if _, err := NewTypedSource("test-adapter", "anything", nil); err != nil || !called {
+		t.Errorf("expected the registered adapter to build the source, err = %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a name twice to panic")
+		}
+	}()
+	RegisterAdapter("test-adapter", jsonAdapter)
+}
@@ -0,0 +1,184 @@
+package rss
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// AtomFeed is the root <feed> element of an Atom 1.0 document
+type AtomFeed struct {
+	Title    AtomText    `xml:"title"`
+	Subtitle AtomText    `xml:"subtitle"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      AtomText       `xml:"title"`
+	Links      []AtomLink     `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Authors    []AtomPerson   `xml:"author"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"content"`
+	Categories []AtomCategory `xml:"category"`
+	XMLBase    string         `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+}
+
+type AtomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+}
+
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// AtomText holds Atom text constructs, which can be plain text, escaped html
+// or inline xhtml markup depending on the type attribute
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Body  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// String returns the text content, keeping the markup of xhtml constructs
+func (t AtomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Body)
+}
+
+// alternateLink returns the href of the rel="alternate" link, falling back
+// to the first link with an href when there is none
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	for _, link := range links {
+		if link.Href != "" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// relLink returns the href of the first link with the rel, like the "hub"
+// and "self" links WebSub publishers add to their feeds
+func relLink(links []AtomLink, rel string) string {
+	for _, link := range links {
+		if link.Rel == rel && link.Href != "" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// enclosureLinks returns the rel="enclosure" links as enclosures
+func enclosureLinks(links []AtomLink) []RSSEnclosure {
+	var enclosures []RSSEnclosure
+	for _, link := range links {
+		if link.Rel == "enclosure" && link.Href != "" {
+			enclosures = append(enclosures, RSSEnclosure{
+				URL:    strings.TrimSpace(link.Href),
+				Length: link.Length,
+				Type:   link.Type,
+			})
+		}
+	}
+	return enclosures
+}
+
+// decodeAtom decodes the children of an Atom <feed> whose root was just read
+func decodeAtom(decoder *xml.Decoder, limits Limits) (*RSSFeed, error) {
+	var atomData AtomFeed
+	err := eachChild(decoder, func(start xml.StartElement) error {
+		if start.Name.Space != atomNamespace && start.Name.Space != "" {
+			return decoder.Skip()
+		}
+		switch start.Name.Local {
+		case "title":
+			return decoder.DecodeElement(&atomData.Title, &start)
+		case "subtitle":
+			return decoder.DecodeElement(&atomData.Subtitle, &start)
+		case "link":
+			var link AtomLink
+			if err := decoder.DecodeElement(&link, &start); err != nil {
+				return err
+			}
+			atomData.Links = append(atomData.Links, link)
+			return nil
+		case "entry":
+			if err := limits.checkItems(len(atomData.Entries)); err != nil {
+				return err
+			}
+			var entry AtomEntry
+			if err := decoder.DecodeElement(&entry, &start); err != nil {
+				return err
+			}
+			atomData.Entries = append(atomData.Entries, entry)
+			return nil
+		default:
+			return decoder.Skip()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return atomData.toRSSFeed(), nil
+}
+
+// toRSSFeed normalizes the Atom feed into the RSSFeed model used by the rest of gator
+func (f *AtomFeed) toRSSFeed() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = f.Title.String()
+	feed.Channel.Link = alternateLink(f.Links)
+	feed.Channel.Description = f.Subtitle.String()
+	feed.Channel.Hub = relLink(f.Links, "hub")
+	feed.Channel.Self = relLink(f.Links, "self")
+
+	for _, entry := range f.Entries {
+		item := RSSItem{
+			Title:       entry.Title.String(),
+			Link:        alternateLink(entry.Links),
+			GUID:        strings.TrimSpace(entry.ID),
+			Description: entry.Summary.String(),
+			Content:     entry.Content.String(),
+			PubDate:     strings.TrimSpace(entry.Published),
+			Enclosures:  enclosureLinks(entry.Links),
+			XMLBase:     strings.TrimSpace(entry.XMLBase),
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, firstNonEmpty(category.Term, category.Label))
+		}
+		for _, author := range entry.Authors {
+			if name := firstNonEmpty(author.Name, author.Email); name != "" {
+				item.Author = strings.TrimSpace(name)
+				break
+			}
+		}
+		if item.Description == "" {
+			item.Description = item.Content
+		}
+		if item.PubDate == "" {
+			item.PubDate = strings.TrimSpace(entry.Updated)
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return &feed
+}
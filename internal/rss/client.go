@@ -0,0 +1,228 @@
+package rss
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ClientOptions configure the HTTP client feeds and pages are fetched with,
+// zero values use DefaultClientOptions
+type ClientOptions struct {
+	// Timeout bounds each attempt, including reading the body
+	Timeout time.Duration
+	// Retries is how many times a transient error is retried, nil uses the
+	// default and 0 disables retries
+	Retries *int
+	// Backoff is the wait before the first retry, it doubles on each retry
+	Backoff time.Duration
+	// ProxyURL is the proxy requests go through, the HTTP_PROXY, HTTPS_PROXY
+	// and NO_PROXY environment variables are used when it is empty
+	ProxyURL string
+	// CABundle is the path of a PEM file with certificates trusted on top of
+	// the system ones
+	CABundle  string
+	UserAgent string
+}
+
+var DefaultClientOptions = ClientOptions{
+	Timeout:   10 * time.Second,
+	Retries:   &defaultRetries,
+	Backoff:   time.Second,
+	UserAgent: "Gator/1.0 (Linux; Custom Client)",
+}
+
+var defaultRetries = 2
+
+// longest wait between two attempts
+const maxBackoff = 30 * time.Second
+
+func (o ClientOptions) withDefaults() ClientOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultClientOptions.Timeout
+	}
+	if o.Retries == nil {
+		o.Retries = DefaultClientOptions.Retries
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DefaultClientOptions.Backoff
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultClientOptions.UserAgent
+	}
+	return o
+}
+
+// Client fetches feeds and pages. It keeps one http.Client so connections are
+// reused between feeds, and retries transient errors with exponential backoff
+type Client struct {
+	httpClient *http.Client
+	userAgent  string
+	retries    int
+	backoff    time.Duration
+}
+
+// DefaultClient is the client used by the package level functions
+var DefaultClient = func() *Client {
+	client, err := NewClient(ClientOptions{})
+	if err != nil {
+		panic(err)
+	}
+	return client
+}()
+
+// NewClient builds a client from the options, failing when the proxy url or
+// the CA bundle can't be used
+func NewClient(opts ClientOptions) (*Client, error) {
+	opts = opts.withDefaults()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q", opts.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.CABundle != "" {
+		roots, err := certPool(opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: transport,
+		},
+		userAgent: opts.UserAgent,
+		retries:   max(*opts.Retries, 0),
+		backoff:   opts.Backoff,
+	}, nil
+}
+
+// HTTPClient returns the http.Client requests are sent with, for requests
+// other than fetches, which aren't retried
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// certPool returns the system certificates along with the ones of the bundle
+func certPool(bundlePath string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read CA bundle: %w", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle %s has no PEM certificates", bundlePath)
+	}
+	return roots, nil
+}
+
+// get requests the url with the extra headers. The response is returned with
+// its body open for 200 and 304 responses, the caller must close it. Network
+// errors and transient statuses are retried
+func (c *Client) get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create request for RSS feed: %w", err)
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(req.Clone(ctx))
+		if err != nil {
+			err = fmt.Errorf("couldn't get a response from the RSS feed: %w", err)
+		} else if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+			resp.Body.Close()
+			err = &statusError{status: resp.Status, code: resp.StatusCode, retryAfter: retryAfter(resp)}
+		}
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= c.retries || !isTransient(ctx, err) {
+			return nil, err
+		}
+
+		wait := min(c.backoff<<attempt, maxBackoff)
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.retryAfter > wait {
+			// servers asking for a longer pause are tried again on the next fetch
+			if statusErr.retryAfter > maxBackoff {
+				return nil, err
+			}
+			wait = statusErr.retryAfter
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// statusError is an unexpected HTTP status
+type statusError struct {
+	status     string
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status: %s", e.status)
+}
+
+// isTransient reports whether the request may succeed when it is sent again
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.code {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// untrusted certificates don't change between attempts
+	var certErr *tls.CertificateVerificationError
+	return !errors.As(err, &certErr)
+}
+
+// retryAfter returns the wait asked by the Retry-After header, in seconds or
+// as a date, or 0 when there is none
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+package rss
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Retries(t *testing.T) {
+	two, none := 2, 0
+	tests := []struct {
+		name             string
+		failures         int
+		status           int
+		retries          *int
+		expectedAttempts int32
+		expectError      bool
+	}{
+		{
+			name:             "transient errors are retried",
+			failures:         2,
+			status:           http.StatusServiceUnavailable,
+			retries:          &two,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "retries exhausted",
+			failures:         3,
+			status:           http.StatusBadGateway,
+			retries:          &two,
+			expectedAttempts: 3,
+			expectError:      true,
+		},
+		{
+			name:             "client errors are not retried",
+			failures:         1,
+			status:           http.StatusNotFound,
+			retries:          &two,
+			expectedAttempts: 1,
+			expectError:      true,
+		},
+		{
+			name:             "default retries",
+			failures:         2,
+			status:           http.StatusServiceUnavailable,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "retries disabled",
+			failures:         1,
+			status:           http.StatusServiceUnavailable,
+			retries:          &none,
+			expectedAttempts: 1,
+			expectError:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(attempts.Add(1)) <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write([]byte(`<rss><channel><title>Retried</title></channel></rss>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(ClientOptions{Retries: tt.retries, Backoff: time.Millisecond})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			result, err := client.FetchFeedConditional(context.Background(), server.URL, CacheValidators{}, DefaultLimits)
+			if got := attempts.Load(); got != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, got)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchFeedConditional() error = %v", err)
+			}
+			if result.Feed.Channel.Title != "Retried" {
+				t.Errorf("expected feed title %q, got %q", "Retried", result.Feed.Channel.Title)
+			}
+		})
+	}
+}
+
+func TestClient_RetryAfterTooLong(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientOptions{Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.FetchFeedConditional(context.Background(), server.URL, CacheValidators{}, DefaultLimits); err == nil {
+		t.Error("expected error but got none")
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("expected the client to give up after 1 attempt, got %d", got)
+	}
+}
+
+func TestClient_UserAgentAndProxy(t *testing.T) {
+	var userAgent, proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		proxied = r.URL.String()
+		w.Write([]byte(`<rss><channel><title>Proxied</title></channel></rss>`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(ClientOptions{ProxyURL: proxy.URL, UserAgent: "Corp Reader"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	result, err := client.FetchFeedConditional(context.Background(), "http://feeds.example.invalid/feed.xml", CacheValidators{}, DefaultLimits)
+	if err != nil {
+		t.Fatalf("FetchFeedConditional() error = %v", err)
+	}
+
+	if result.Feed.Channel.Title != "Proxied" {
+		t.Errorf("expected feed title %q, got %q", "Proxied", result.Feed.Channel.Title)
+	}
+	if proxied != "http://feeds.example.invalid/feed.xml" {
+		t.Errorf("expected the proxy to get the feed url, got %q", proxied)
+	}
+	if userAgent != "Corp Reader" {
+		t.Errorf("expected user agent %q, got %q", "Corp Reader", userAgent)
+	}
+}
+
+func TestClient_CABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><title>Internal</title></channel></rss>`))
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	noRetries := 0
+	untrusted, err := NewClient(ClientOptions{Retries: &noRetries})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := untrusted.FetchFeedConditional(context.Background(), server.URL, CacheValidators{}, DefaultLimits); err == nil {
+		t.Error("expected the certificate to be rejected without the CA bundle")
+	}
+
+	trusted, err := NewClient(ClientOptions{CABundle: bundle})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	result, err := trusted.FetchFeedConditional(context.Background(), server.URL, CacheValidators{}, DefaultLimits)
+	if err != nil {
+		t.Fatalf("FetchFeedConditional() error = %v", err)
+	}
+	if result.Feed.Channel.Title != "Internal" {
+		t.Errorf("expected feed title %q, got %q", "Internal", result.Feed.Channel.Title)
+	}
+}
+
+func TestNewClient_Errors(t *testing.T) {
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts ClientOptions
+	}{
+		{
+			name: "invalid proxy url",
+			opts: ClientOptions{ProxyURL: "://proxy"},
+		},
+		{
+			name: "missing CA bundle",
+			opts: ClientOptions{CABundle: filepath.Join(t.TempDir(), "missing.pem")},
+		},
+		{
+			name: "CA bundle without certificates",
+			opts: ClientOptions{CABundle: notPEM},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient(tt.opts); err == nil {
+				t.Error("expected error but got none")
+			}
+		})
+	}
+}
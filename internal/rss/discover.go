@@ -0,0 +1,164 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// FeedLink is a feed candidate advertised by an HTML page
+type FeedLink struct {
+	Title string
+	URL   string
+	Type  string
+}
+
+// media types advertised in <link rel="alternate"> that gator knows how to parse
+var feedMediaTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+}
+
+// DiscoverFeeds calls DiscoverFeeds on DefaultClient
+func DiscoverFeeds(ctx context.Context, pageURL string, limits Limits) ([]FeedLink, error) {
+	return DefaultClient.DiscoverFeeds(ctx, pageURL, limits)
+}
+
+// DiscoverFeeds fetches pageURL and returns the feeds it points to. When the
+// url already serves a feed it is returned as the only candidate, when it serves
+// an HTML page the feeds advertised in its <link rel="alternate"> tags are
+// returned. Pages are read within the same limits as feeds
+func (c *Client) DiscoverFeeds(ctx context.Context, pageURL string, limits Limits) ([]FeedLink, error) {
+	limits = limits.withDefaults()
+	// local files have no page to discover feeds from, they must be the feed
+	if parsed, err := url.Parse(pageURL); err == nil && parsed.Scheme == "file" {
+		result, err := c.FetchFeedConditional(ctx, pageURL, CacheValidators{}, limits)
+		if err != nil {
+			return nil, fmt.Errorf("file is not a feed: %w", err)
+		}
+		return []FeedLink{{
+			Title: result.Feed.Channel.Title,
+			URL:   pageURL,
+		}}, nil
+	}
+
+	resp, err := c.get(ctx, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	limitedBody, err := limitBody(resp, limits)
+	if err != nil {
+		return nil, err
+	}
+	body := bufio.NewReader(limitedBody)
+	head, _ := body.Peek(sniffLen)
+
+	contentType := resp.Header.Get("Content-Type")
+	if !isHTML(contentType, head) {
+		feedData, err := parseFeed(body, resp.Request.URL, contentType, limits)
+		if err != nil {
+			return nil, fmt.Errorf("url is neither a feed nor an HTML page: %w", err)
+		}
+		return []FeedLink{{
+			Title: feedData.Channel.Title,
+			URL:   pageURL,
+			Type:  contentType,
+		}}, nil
+	}
+
+	return findFeedLinks(body, resp.Request.URL)
+}
+
+// isHTML reports whether the response is an HTML page rather than a feed
+func isHTML(contentType string, body []byte) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			return true
+		case "text/plain", "application/octet-stream":
+			// generic types say nothing about the content, sniff the body instead
+		default:
+			return false
+		}
+	}
+	return strings.HasPrefix(http.DetectContentType(bytes.TrimSpace(body)), "text/html")
+}
+
+// findFeedLinks collects the feed <link> tags of an HTML document, resolving
+// their hrefs against the page url and any <base href>
+func findFeedLinks(page io.Reader, pageURL *url.URL) ([]FeedLink, error) {
+	base := pageURL
+	seen := map[string]bool{}
+	links := []FeedLink{}
+
+	z := html.NewTokenizer(page)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("couldn't read HTML page: %w", err)
+			}
+			return links, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			attrs := map[string]string{}
+			for _, attr := range token.Attr {
+				attrs[strings.ToLower(attr.Key)] = strings.TrimSpace(attr.Val)
+			}
+
+			switch token.Data {
+			case "base":
+				if ref, err := url.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+					base = pageURL.ResolveReference(ref)
+				}
+			case "link":
+				if !hasRel(attrs["rel"], "alternate") {
+					continue
+				}
+				mediaType := strings.ToLower(attrs["type"])
+				if !feedMediaTypes[mediaType] || attrs["href"] == "" {
+					continue
+				}
+				ref, err := url.Parse(attrs["href"])
+				if err != nil {
+					continue
+				}
+				feedURL := base.ResolveReference(ref).String()
+				if seen[feedURL] {
+					continue
+				}
+				seen[feedURL] = true
+				links = append(links, FeedLink{
+					Title: attrs["title"],
+					URL:   feedURL,
+					Type:  mediaType,
+				})
+			}
+		}
+	}
+}
+
+// hasRel reports whether the space separated rel attribute contains value
+func hasRel(rel, value string) bool {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if r == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverFeeds(t *testing.T) {
+	tests := []struct {
+		name          string
+		fixture       string
+		contentType   string
+		expectedLinks func(serverURL string) []FeedLink
+		expectError   bool
+	}{
+		{
+			name:        "HTML page with feed links",
+			fixture:     "page.html",
+			contentType: "text/html; charset=utf-8",
+			expectedLinks: func(serverURL string) []FeedLink {
+				return []FeedLink{
+					{Title: "Gator Blog RSS", URL: serverURL + "/feed.xml", Type: "application/rss+xml"},
+					{Title: "Gator Blog Atom", URL: "https://example.com/atom.xml", Type: "application/atom+xml"},
+				}
+			},
+		},
+		{
+			name:        "url is already a feed",
+			fixture:     "rss2.xml",
+			contentType: "application/rss+xml",
+			expectedLinks: func(serverURL string) []FeedLink {
+				return []FeedLink{
+					{Title: "Gator Test Blog", URL: serverURL, Type: "application/rss+xml"},
+				}
+			},
+		},
+		{
+			name:        "HTML page detected without Content-Type",
+			fixture:     "page.html",
+			contentType: "text/plain",
+			expectedLinks: func(serverURL string) []FeedLink {
+				return []FeedLink{
+					{Title: "Gator Blog RSS", URL: serverURL + "/feed.xml", Type: "application/rss+xml"},
+					{Title: "Gator Blog Atom", URL: "https://example.com/atom.xml", Type: "application/atom+xml"},
+				}
+			},
+		},
+		{
+			name:        "neither a feed nor HTML",
+			fixture:     "page.html",
+			contentType: "image/png",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := readFixture(t, tt.fixture)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			got, err := DiscoverFeeds(context.Background(), server.URL, DefaultLimits)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DiscoverFeeds() error = %v", err)
+			}
+
+			expected := tt.expectedLinks(server.URL)
+			if len(got) != len(expected) {
+				t.Fatalf("expected %d feeds, got %d: %+v", len(expected), len(got), got)
+			}
+			for i := range expected {
+				if got[i] != expected[i] {
+					t.Errorf("feed %d: expected %+v, got %+v", i, expected[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverFeeds_Limits(t *testing.T) {
+	body := readFixture(t, "page.html")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	_, err := DiscoverFeeds(context.Background(), server.URL, Limits{MaxBodySize: 64})
+	if !errors.Is(err, ErrMaxBodySize) {
+		t.Errorf("expected ErrMaxBodySize for a page larger than the limit, got %v", err)
+	}
+}
+
+func TestFindFeedLinks_BaseHref(t *testing.T) {
+	page := strings.NewReader(`<html><head>
+<base href="https://cdn.example.com/blog/">
+<link rel="alternate feed" type="application/feed+json" href="feed.json">
+</head></html>`)
+	pageURL, _ := url.Parse("https://example.com/")
+
+	got, err := findFeedLinks(page, pageURL)
+	if err != nil {
+		t.Fatalf("findFeedLinks() error = %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 feed, got %d", len(got))
+	}
+	if got[0].URL != "https://cdn.example.com/blog/feed.json" {
+		t.Errorf("expected url resolved against <base>, got %q", got[0].URL)
+	}
+}
@@ -0,0 +1,145 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// JSONFeed is a JSON Feed 1.1 document, see https://www.jsonfeed.org/version/1.1/
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Hubs        []JSONFeedHub  `json:"hubs"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	ExternalURL   string               `json:"external_url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Tags          []string             `json:"tags"`
+	Authors       []JSONFeedAuthor     `json:"authors"`
+	Author        *JSONFeedAuthor      `json:"author"`
+	Attachments   []JSONFeedAttachment `json:"attachments"`
+}
+
+// JSONFeedAuthor is an item author, version 1.0 feeds use a single "author"
+// object while 1.1 feeds use the "authors" array
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// JSONFeedHub is an endpoint that pushes the feed's updates, like a WebSub hub
+type JSONFeedHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type JSONFeedAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	SizeInBytes       int64   `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+// isJSONFeed reports whether the response is a JSON Feed, trusting the
+// Content-Type when it is a JSON media type and sniffing the body otherwise
+func isJSONFeed(contentType string, body []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/feed+json", "application/json":
+			return true
+		}
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+// decodeJSONFeed decodes a JSON Feed document. The document is decoded whole,
+// the body size limit of the reader keeps it bounded
+func decodeJSONFeed(r io.Reader, limits Limits) (*RSSFeed, error) {
+	var jsonData JSONFeed
+	if err := json.NewDecoder(r).Decode(&jsonData); err != nil {
+		return nil, err
+	}
+	if len(jsonData.Items) > limits.MaxItems {
+		return nil, fmt.Errorf("%w: feed has more than %d items", ErrMaxItems, limits.MaxItems)
+	}
+	return jsonData.toRSSFeed(), nil
+}
+
+// toRSSFeed normalizes the JSON Feed into the RSSFeed model used by the rest of gator
+func (f *JSONFeed) toRSSFeed() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = strings.TrimSpace(f.Title)
+	feed.Channel.Link = strings.TrimSpace(f.HomePageURL)
+	feed.Channel.Description = strings.TrimSpace(f.Description)
+	feed.Channel.Self = strings.TrimSpace(f.FeedURL)
+	for _, hub := range f.Hubs {
+		if strings.EqualFold(hub.Type, "websub") && hub.URL != "" {
+			feed.Channel.Hub = strings.TrimSpace(hub.URL)
+			break
+		}
+	}
+
+	for _, entry := range f.Items {
+		item := RSSItem{
+			Title:       strings.TrimSpace(entry.Title),
+			Link:        strings.TrimSpace(firstNonEmpty(entry.URL, entry.ExternalURL)),
+			GUID:        strings.TrimSpace(entry.ID),
+			Categories:  entry.Tags,
+			Description: firstNonEmpty(entry.Summary, entry.ContentHTML, entry.ContentText),
+			Content:     firstNonEmpty(entry.ContentHTML, entry.ContentText),
+			PubDate:     strings.TrimSpace(firstNonEmpty(entry.DatePublished, entry.DateModified)),
+		}
+		authors := entry.Authors
+		if entry.Author != nil {
+			authors = append(authors, *entry.Author)
+		}
+		for _, author := range authors {
+			if name := firstNonEmpty(author.Name, author.URL); name != "" {
+				item.Author = strings.TrimSpace(name)
+				break
+			}
+		}
+		for _, attachment := range entry.Attachments {
+			enclosure := RSSEnclosure{
+				URL:  strings.TrimSpace(attachment.URL),
+				Type: attachment.MimeType,
+			}
+			if attachment.SizeInBytes > 0 {
+				enclosure.Length = strconv.FormatInt(attachment.SizeInBytes, 10)
+			}
+			item.Enclosures = append(item.Enclosures, enclosure)
+			if item.Duration == "" && attachment.DurationInSeconds > 0 {
+				item.Duration = strconv.Itoa(int(attachment.DurationInSeconds))
+			}
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return &feed
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package rss
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONMapping tells where the items of a JSON API response and their fields
+// are, as dot separated paths of object keys and array indexes such as
+// "data.children" or "user.login". Items is the path of the array of items
+// from the root of the response, empty when the root is the array, the
+// other paths start at each item
+type JSONMapping struct {
+	Items string `json:"items"`
+	Title string `json:"title"`
+	Link  string `json:"link"`
+	// ID tells items apart, the link is used when it is empty
+	ID string `json:"id,omitempty"`
+	// Date can be a date string or a unix timestamp in seconds or milliseconds
+	Date        string `json:"date,omitempty"`
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
+}
+
+// Validate reports whether the mapping can be used, it needs a title or a link path
+func (m JSONMapping) Validate() error {
+	if m.Title == "" && m.Link == "" {
+		return errors.New("json mapping needs a title or a link path")
+	}
+	for _, path := range []string{m.Items, m.Title, m.Link, m.ID, m.Date, m.Description, m.Author} {
+		if path == "" {
+			continue
+		}
+		for key := range strings.SplitSeq(path, ".") {
+			if key == "" {
+				return fmt.Errorf("invalid json path %q", path)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonAdapter reads the JSON API at the url with the mapping of the config
+func jsonAdapter(c *Client, apiURL string, config json.RawMessage) (Source, error) {
+	var mapping JSONMapping
+	if err := decodeConfig(config, &mapping); err != nil {
+		return nil, err
+	}
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+	return JSONSource{URL: apiURL, Mapping: mapping, Client: c}, nil
+}
+
+// JSONSource reads the items of a JSON API that has no feed, such as a
+// search endpoint, with the paths of the mapping. A nil Client uses DefaultClient
+type JSONSource struct {
+	URL     string
+	Mapping JSONMapping
+	Client  *Client
+}
+
+func (src JSONSource) Fetch(ctx context.Context, validators CacheValidators, limits Limits) (*FetchResult, error) {
+	limits = limits.withDefaults()
+	if err := src.Mapping.Validate(); err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	if validators.ETag != "" {
+		header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	client := src.Client
+	if client == nil {
+		client = DefaultClient
+	}
+	resp, err := client.get(ctx, src.URL, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &FetchResult{
+			Validators:  validators,
+			NotModified: true,
+			MovedTo:     permanentLocation(resp),
+		}, nil
+	}
+
+	body, err := limitBody(resp, limits)
+	if err != nil {
+		return nil, err
+	}
+	var document any
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("couldn't decode JSON response: %w", err)
+	}
+
+	feedData, err := mapJSONItems(document, src.Mapping, limits)
+	if err != nil {
+		return nil, err
+	}
+	resolveLinks(feedData, resp.Request.URL)
+
+	return &FetchResult{
+		Feed: feedData,
+		Validators: CacheValidators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+		MovedTo: permanentLocation(resp),
+	}, nil
+}
+
+// mapJSONItems turns the array at the items path into feed items, items
+// without a title or a link are skipped
+func mapJSONItems(document any, mapping JSONMapping, limits Limits) (*RSSFeed, error) {
+	elements, ok := jsonPath(document, mapping.Items).([]any)
+	if !ok {
+		return nil, fmt.Errorf("json path %q is not an array of items", mapping.Items)
+	}
+
+	var feed RSSFeed
+	for _, element := range elements {
+		item := RSSItem{
+			Title:       jsonString(jsonPath(element, mapping.Title)),
+			Link:        jsonString(jsonPath(element, mapping.Link)),
+			GUID:        jsonString(jsonPath(element, mapping.ID)),
+			Description: jsonString(jsonPath(element, mapping.Description)),
+			Author:      jsonString(jsonPath(element, mapping.Author)),
+			PubDate:     jsonDate(jsonPath(element, mapping.Date)),
+		}
+		if item.Title == "" && item.Link == "" {
+			continue
+		}
+
+		if err := limits.checkItems(len(feed.Channel.Item)); err != nil {
+			return nil, err
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return &feed, nil
+}
+
+// jsonPath returns the value at the dot separated path, nil when the path
+// doesn't exist. An empty path is the value itself
+func jsonPath(value any, path string) any {
+	if path == "" {
+		return value
+	}
+	for key := range strings.SplitSeq(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			value = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			value = node[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// jsonString returns strings, numbers and booleans as text, other values
+// have no text
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// jsonDate returns the date as text for pubdate, unix timestamps are
+// converted to RFC 3339
+func jsonDate(value any) string {
+	number, ok := value.(json.Number)
+	if !ok {
+		return jsonString(value)
+	}
+	seconds, err := number.Float64()
+	if err != nil || seconds <= 0 {
+		return ""
+	}
+	// timestamps this large are in milliseconds
+	if seconds > 1e11 {
+		seconds /= 1000
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,123 @@
+package rss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// hits of a search API, like the Hacker News search
+const searchResponse = `{"hits": [
+  {"objectID": "1", "title": "Show HN: gator", "url": "https://example.com/gator", "author": "pg", "created_at": "2025-01-06T10:00:00Z"},
+  {"objectID": "2", "title": "Ask HN: feeds?", "url": null, "author": "dang", "created_at_i": 1736150400},
+  {"objectID": "3"}
+], "nbHits": 3}`
+
+// listing of a forum, like a Reddit listing
+const listingResponse = `{"data": {"children": [
+  {"data": {"name": "t3_a", "title": "Feeds in Go", "permalink": "/r/golang/comments/a/feeds_in_go/", "created_utc": 1736150400.0, "author": "gopher", "selftext": "How do you read feeds?"}},
+  {"data": {"name": "t3_b", "title": "Release notes", "permalink": "/r/golang/comments/b/release_notes/", "created_utc": 1736064000000}}
+]}}`
+
+// top level array, like a GitHub issues list
+const arrayResponse = `[
+  {"id": 10, "title": "Crash on empty feed", "html_url": "https://github.com/o/r/issues/10", "user": {"login": "octocat"}, "labels": [{"name": "bug"}]},
+  {"id": 11, "title": "Support Gemini", "html_url": "https://github.com/o/r/issues/11", "user": {"login": "hubot"}, "labels": []}
+]`
+
+func TestJSONSource(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		mapping       JSONMapping
+		expectedItems []RSSItem
+		expectError   bool
+	}{
+		{
+			name:    "search hits",
+			body:    searchResponse,
+			mapping: JSONMapping{Items: "hits", Title: "title", Link: "url", ID: "objectID", Date: "created_at", Author: "author"},
+			expectedItems: []RSSItem{
+				{GUID: "1", Title: "Show HN: gator", Link: "https://example.com/gator", Author: "pg", PubDate: "2025-01-06T10:00:00Z"},
+				{GUID: "2", Title: "Ask HN: feeds?", Author: "dang"},
+			},
+		},
+		{
+			name:    "nested listing with unix dates",
+			body:    listingResponse,
+			mapping: JSONMapping{Items: "data.children", Title: "data.title", Link: "data.permalink", ID: "data.name", Date: "data.created_utc", Description: "data.selftext", Author: "data.author"},
+			expectedItems: []RSSItem{
+				{GUID: "t3_a", Title: "Feeds in Go", Link: "/r/golang/comments/a/feeds_in_go/", PubDate: "2025-01-06T08:00:00Z", Description: "How do you read feeds?", Author: "gopher"},
+				{GUID: "t3_b", Title: "Release notes", Link: "/r/golang/comments/b/release_notes/", PubDate: "2025-01-05T08:00:00Z"},
+			},
+		},
+		{
+			name:    "array at the root",
+			body:    arrayResponse,
+			mapping: JSONMapping{Title: "title", Link: "html_url", ID: "id", Author: "user.login", Description: "labels.0.name"},
+			expectedItems: []RSSItem{
+				{GUID: "10", Title: "Crash on empty feed", Link: "https://github.com/o/r/issues/10", Author: "octocat", Description: "bug"},
+				{GUID: "11", Title: "Support Gemini", Link: "https://github.com/o/r/issues/11", Author: "hubot"},
+			},
+		},
+		{
+			name:        "items path is not an array",
+			body:        searchResponse,
+			mapping:     JSONMapping{Items: "nbHits", Title: "title"},
+			expectError: true,
+		},
+		{
+			name:        "not JSON",
+			body:        "<html></html>",
+			mapping:     JSONMapping{Title: "title"},
+			expectError: true,
+		},
+		{
+			name:        "no title or link path",
+			body:        searchResponse,
+			mapping:     JSONMapping{Items: "hits"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept") != "application/json" {
+					t.Errorf("expected JSON to be requested, got Accept %q", r.Header.Get("Accept"))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			source := JSONSource{URL: server.URL + "/api", Mapping: tt.mapping}
+			result, err := source.Fetch(context.Background(), CacheValidators{}, DefaultLimits)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			items := result.Feed.Channel.Item
+			if len(items) != len(tt.expectedItems) {
+				t.Fatalf("expected %d items, got %d", len(tt.expectedItems), len(items))
+			}
+			for i, expected := range tt.expectedItems {
+				if len(expected.Link) > 0 && expected.Link[0] == '/' {
+					expected.Link = server.URL + expected.Link
+				}
+				got := items[i]
+				if got.GUID != expected.GUID || got.Title != expected.Title || got.Link != expected.Link ||
+					got.PubDate != expected.PubDate || got.Description != expected.Description || got.Author != expected.Author {
+					t.Errorf("expected item %+v, got %+v", expected, got)
+				}
+			}
+		})
+	}
+}
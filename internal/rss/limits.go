@@ -0,0 +1,65 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrMaxBodySize = errors.New("max body size exceeded")
+	ErrMaxItems    = errors.New("max item count exceeded")
+)
+
+// Limits bound how much of a feed is read, zero values use DefaultLimits
+type Limits struct {
+	MaxBodySize int64
+	MaxItems    int
+}
+
+var DefaultLimits = Limits{
+	MaxBodySize: 10 << 20,
+	MaxItems:    1000,
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxBodySize <= 0 {
+		l.MaxBodySize = DefaultLimits.MaxBodySize
+	}
+	if l.MaxItems <= 0 {
+		l.MaxItems = DefaultLimits.MaxItems
+	}
+	return l
+}
+
+// checkItems returns an error when one more item would go over MaxItems
+func (l Limits) checkItems(count int) error {
+	if count >= l.MaxItems {
+		return fmt.Errorf("%w: feed has more than %d items", ErrMaxItems, l.MaxItems)
+	}
+	return nil
+}
+
+// limitedReader fails with ErrMaxBodySize once more than limit bytes are read,
+// unlike io.LimitReader which would silently truncate the feed
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func newLimitedReader(r io.Reader, limit int64) *limitedReader {
+	return &limitedReader{r: r, limit: limit}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if remaining := l.limit - l.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return 0, fmt.Errorf("%w: feed is larger than %d bytes", ErrMaxBodySize, l.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,127 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchFeedConditional_MaxBodySize(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunked bool
+	}{
+		{
+			name:    "announced Content-Length over the limit",
+			chunked: false,
+		},
+		{
+			name:    "chunked body over the limit",
+			chunked: true,
+		},
+	}
+
+	body := readFixture(t, "rss2.xml")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.chunked {
+					w.Write(body[:100])
+					w.(http.Flusher).Flush()
+					w.Write(body[100:])
+					return
+				}
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			_, err := FetchFeedConditional(context.Background(), server.URL, CacheValidators{}, Limits{MaxBodySize: 200})
+
+			if !errors.Is(err, ErrMaxBodySize) {
+				t.Fatalf("expected ErrMaxBodySize, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "200 bytes") {
+				t.Errorf("expected error to name the 200 bytes limit, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestParseFeed_MaxItems(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixture     string
+		maxItems    int
+		expectError bool
+	}{
+		{
+			name:     "RSS 2.0 within the limit",
+			fixture:  "rss2.xml",
+			maxItems: 2,
+		},
+		{
+			name:        "RSS 2.0 over the limit",
+			fixture:     "rss2.xml",
+			maxItems:    1,
+			expectError: true,
+		},
+		{
+			name:        "Atom over the limit",
+			fixture:     "atom.xml",
+			maxItems:    1,
+			expectError: true,
+		},
+		{
+			name:        "RSS 1.0 over the limit",
+			fixture:     "rdf.xml",
+			maxItems:    1,
+			expectError: true,
+		},
+		{
+			name:        "JSON Feed over the limit",
+			fixture:     "jsonfeed.json",
+			maxItems:    1,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFeed(bytes.NewReader(readFixture(t, tt.fixture)), nil, "", Limits{MaxItems: tt.maxItems})
+
+			if tt.expectError {
+				if !errors.Is(err, ErrMaxItems) {
+					t.Fatalf("expected ErrMaxItems, got %v", err)
+				}
+				if !strings.Contains(err.Error(), "more than 1 items") {
+					t.Errorf("expected error to name the limit, got %q", err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFeed() error = %v", err)
+			}
+			if len(got.Channel.Item) != tt.maxItems {
+				t.Errorf("expected %d items, got %d", tt.maxItems, len(got.Channel.Item))
+			}
+		})
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	var out bytes.Buffer
+	_, err := out.ReadFrom(newLimitedReader(strings.NewReader("12345"), 5))
+	if err != nil || out.String() != "12345" {
+		t.Errorf("expected body at the limit to be read whole, got %q, %v", out.String(), err)
+	}
+
+	out.Reset()
+	_, err = out.ReadFrom(newLimitedReader(strings.NewReader("123456"), 5))
+	if !errors.Is(err, ErrMaxBodySize) {
+		t.Errorf("expected ErrMaxBodySize for a body over the limit, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package rss
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ManoloEsS/gator_cli/internal/mailbox"
+)
+
+// MailConfig is the config of mail sources, Path is the mailbox of the
+// sender of a mailto url
+type MailConfig struct {
+	Path string `json:"path"`
+}
+
+// mailAdapter reads newsletters from a local mailbox. A mailto url is the
+// feed of one sender, read from the mailbox in the config, and a file url
+// is a mailbox read as a single feed
+func mailAdapter(c *Client, feedURL string, config json.RawMessage) (Source, error) {
+	var mailConfig MailConfig
+	if err := decodeConfig(config, &mailConfig); err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed url %q: %w", feedURL, err)
+	}
+
+	switch parsed.Scheme {
+	case "mailto":
+		if parsed.Opaque == "" {
+			return nil, fmt.Errorf("mailto url %q has no address", feedURL)
+		}
+		if mailConfig.Path == "" {
+			return nil, errors.New("mail source needs the path of the mailbox")
+		}
+		return MailSource{Path: mailConfig.Path, From: parsed.Opaque}, nil
+	case "file":
+		path, err := filePath(parsed)
+		if err != nil {
+			return nil, err
+		}
+		return MailSource{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported mail url scheme %q", parsed.Scheme)
+	}
+}
+
+// MailURL returns the url of the feed of the newsletters sent by address
+func MailURL(address string) string {
+	return "mailto:" + strings.ToLower(address)
+}
+
+// MailSenders returns the senders of the messages of the mailbox at path,
+// each can be read as a feed of its own with the url of MailURL
+func MailSenders(path string, limits Limits) ([]mail.Address, error) {
+	return mailbox.Senders(path, limits.withDefaults().MaxBodySize)
+}
+
+// MailSource reads the messages of a Maildir directory or mbox file, every
+// message is an item. The modification time of the mailbox is used as its
+// Last-Modified validator
+type MailSource struct {
+	Path string
+	// From keeps the messages sent by this address, all the messages are
+	// read when it is empty
+	From string
+}
+
+func (src MailSource) Fetch(ctx context.Context, validators CacheValidators, limits Limits) (*FetchResult, error) {
+	limits = limits.withDefaults()
+
+	modTime, err := mailbox.ModTime(src.Path)
+	if err != nil {
+		return nil, err
+	}
+	lastModified := modTime.UTC().Format(http.TimeFormat)
+	if validators.LastModified == lastModified {
+		return &FetchResult{
+			Validators:  validators,
+			NotModified: true,
+		}, nil
+	}
+
+	messages, err := mailbox.Read(src.Path, src.From, limits.MaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FetchResult{
+		Feed:       mailFeed(messages, src.From, limits),
+		Validators: CacheValidators{LastModified: lastModified},
+	}, nil
+}
+
+// mailFeed turns the messages into feed items, newest first. Mailboxes keep
+// every newsletter ever received, so only the newest MaxItems are kept
+// instead of failing like feeds that are too long
+func mailFeed(messages []mailbox.Message, from string, limits Limits) *RSSFeed {
+	slices.SortStableFunc(messages, func(a, b mailbox.Message) int {
+		return b.Date.Compare(a.Date)
+	})
+	if len(messages) > limits.MaxItems {
+		messages = messages[:limits.MaxItems]
+	}
+
+	var feed RSSFeed
+	if from != "" {
+		feed.Channel.Link = MailURL(from)
+		feed.Channel.Title = from
+	}
+	for _, message := range messages {
+		sender := firstNonEmpty(message.From.Name, message.From.Address)
+		if from != "" && message.From.Name != "" && feed.Channel.Title == from {
+			feed.Channel.Title = message.From.Name
+		}
+
+		item := RSSItem{
+			Title:       message.Subject,
+			GUID:        message.ID,
+			Description: message.HTML,
+			Author:      sender,
+		}
+		if item.Description == "" {
+			item.Description = textHTML(message.Text)
+		}
+		if !message.Date.IsZero() {
+			item.PubDate = message.Date.Format(time.RFC1123Z)
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return &feed
+}
+
+// textHTML turns a plain text body into HTML paragraphs, keeping its line breaks
+func textHTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var b strings.Builder
+	for paragraph := range strings.SplitSeq(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(strings.TrimSpace(line))
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>")
+	}
+	return b.String()
+}
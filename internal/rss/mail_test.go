@@ -0,0 +1,94 @@
+package rss
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const newsletters = `From weekly@swamp.example Mon Dec 30 10:00:00 2024
+From: weekly@swamp.example
+Subject: Gators issue 11
+Date: Mon, 30 Dec 2024 10:00:00 +0000
+Message-ID: <issue-11@swamp.example>
+
+Issue 11 & more.
+Second line.
+
+>From the swamp.
+
+From weekly@swamp.example Mon Jan  6 10:00:00 2025
+From: Swamp Weekly <weekly@swamp.example>
+Subject: Gators issue 12
+Date: Mon, 06 Jan 2025 10:00:00 +0000
+Message-ID: <issue-12@swamp.example>
+Content-Type: text/html
+
+<p>Issue 12</p>
+
+From herons@birds.example Sun Jan  5 08:00:00 2025
+From: herons@birds.example
+Subject: Heron season
+
+No date on this one.
+`
+
+func TestMailSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newsletters.mbox")
+	if err := os.WriteFile(path, []byte(newsletters), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("sender", func(t *testing.T) {
+		result, err := MailSource{Path: path, From: "weekly@swamp.example"}.Fetch(context.Background(), CacheValidators{}, DefaultLimits)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+
+		channel := result.Feed.Channel
+		if channel.Title != "Swamp Weekly" || channel.Link != "mailto:weekly@swamp.example" {
+			t.Errorf("expected the feed of the sender, got %q %q", channel.Title, channel.Link)
+		}
+		expectedItems := []RSSItem{
+			{GUID: "issue-12@swamp.example", Title: "Gators issue 12", Description: "<p>Issue 12</p>", Author: "Swamp Weekly", PubDate: "Mon, 06 Jan 2025 10:00:00 +0000"},
+			{GUID: "issue-11@swamp.example", Title: "Gators issue 11", Description: "<p>Issue 11 &amp; more.<br>Second line.</p><p>From the swamp.</p>", Author: "weekly@swamp.example", PubDate: "Mon, 30 Dec 2024 10:00:00 +0000"},
+		}
+		if len(channel.Item) != len(expectedItems) {
+			t.Fatalf("expected %d items, got %d", len(expectedItems), len(channel.Item))
+		}
+		for i, expected := range expectedItems {
+			got := channel.Item[i]
+			if got.GUID != expected.GUID || got.Title != expected.Title || got.Description != expected.Description ||
+				got.Author != expected.Author || got.PubDate != expected.PubDate {
+				t.Errorf("expected item %+v, got %+v", expected, got)
+			}
+		}
+
+		again, err := MailSource{Path: path, From: "weekly@swamp.example"}.Fetch(context.Background(), result.Validators, DefaultLimits)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if !again.NotModified {
+			t.Errorf("expected an unchanged mailbox to be reported not modified")
+		}
+	})
+
+	t.Run("whole mailbox keeps the newest items", func(t *testing.T) {
+		result, err := MailSource{Path: path}.Fetch(context.Background(), CacheValidators{}, Limits{MaxItems: 2})
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		items := result.Feed.Channel.Item
+		if len(items) != 2 || items[0].Title != "Gators issue 12" || items[1].Title != "Gators issue 11" {
+			t.Errorf("expected the 2 newest messages, got %+v", items)
+		}
+	})
+
+	t.Run("missing mailbox", func(t *testing.T) {
+		_, err := MailSource{Path: filepath.Join(t.TempDir(), "missing")}.Fetch(context.Background(), CacheValidators{}, DefaultLimits)
+		if err == nil {
+			t.Errorf("expected error but got none")
+		}
+	})
+}
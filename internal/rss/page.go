@@ -0,0 +1,65 @@
+package rss
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"golang.org/x/net/html/charset"
+)
+
+var ErrNotHTML = errors.New("not an HTML page")
+
+// Page is an HTML page linked from a feed item
+type Page struct {
+	// URL is the final url of the page, after redirects
+	URL *url.URL
+	// Body is the page converted to UTF-8
+	Body []byte
+}
+
+// FetchPage calls FetchPage on DefaultClient
+func FetchPage(ctx context.Context, pageURL string, limits Limits) (*Page, error) {
+	return DefaultClient.FetchPage(ctx, pageURL, limits)
+}
+
+// FetchPage downloads the HTML page at pageURL, such as the article a feed
+// item links to. The page is converted to UTF-8 from the charset of the
+// Content-Type or the one declared in the page, responses that aren't HTML
+// return ErrNotHTML
+func (c *Client) FetchPage(ctx context.Context, pageURL string, limits Limits) (*Page, error) {
+	limits = limits.withDefaults()
+	resp, err := c.get(ctx, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	limitedBody, err := limitBody(resp, limits)
+	if err != nil {
+		return nil, err
+	}
+	body := bufio.NewReader(limitedBody)
+	head, _ := body.Peek(sniffLen)
+
+	contentType := resp.Header.Get("Content-Type")
+	if !isHTML(contentType, head) {
+		return nil, fmt.Errorf("%w: %s is served as %q", ErrNotHTML, pageURL, contentType)
+	}
+
+	reader, err := charset.NewReader(body, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read page %s: %w", pageURL, err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read page %s: %w", pageURL, err)
+	}
+	return &Page{
+		URL:  resp.Request.URL,
+		Body: data,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchPage(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedBody string
+		expectedErr  error
+	}{
+		{
+			name:         "utf-8 page",
+			contentType:  "text/html; charset=utf-8",
+			body:         "<p>Café</p>",
+			expectedBody: "<p>Café</p>",
+		},
+		{
+			name:         "charset from Content-Type",
+			contentType:  "text/html; charset=iso-8859-1",
+			body:         "<p>Caf\xe9</p>",
+			expectedBody: "<p>Café</p>",
+		},
+		{
+			name:         "charset from meta tag",
+			contentType:  "text/html",
+			body:         `<meta charset="windows-1252"><p>Caf` + "\xe9</p>",
+			expectedBody: `<meta charset="windows-1252"><p>Café</p>`,
+		},
+		{
+			name:        "not HTML",
+			contentType: "application/pdf",
+			body:        "%PDF-1.7",
+			expectedErr: ErrNotHTML,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/article" {
+					http.Redirect(w, r, "/article", http.StatusMovedPermanently)
+					return
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			page, err := FetchPage(context.Background(), server.URL+"/post?id=1", DefaultLimits)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchPage() error = %v", err)
+			}
+			if got := string(page.Body); got != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, got)
+			}
+			if !strings.HasSuffix(page.URL.String(), "/article") {
+				t.Errorf("expected the url after redirects, got %s", page.URL)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package rss
+
+import "encoding/xml"
+
+// RDFFeed is the root <rdf:RDF> element of an RSS 1.0 document, where the
+// items are siblings of the channel instead of being nested inside it. It is
+// decoded by decodeRDF
+type RDFFeed struct {
+	Channel struct {
+		Title           string
+		Link            string
+		Description     string
+		XMLBase         string
+		UpdatePeriod    string
+		UpdateFrequency string
+		Links           []AtomLink
+	}
+	Item []RSSItem
+}
+
+// decodeRDF decodes the channel and items of an <rdf:RDF> whose root was just read
+func decodeRDF(decoder *xml.Decoder, limits Limits) (*RSSFeed, error) {
+	var rdfData RDFFeed
+	err := eachChild(decoder, func(start xml.StartElement) error {
+		switch start.Name.Local {
+		case "channel":
+			return decodeRDFChannel(decoder, start, &rdfData)
+		case "item":
+			if err := limits.checkItems(len(rdfData.Item)); err != nil {
+				return err
+			}
+			item, err := decodeItem(decoder, start)
+			if err != nil {
+				return err
+			}
+			rdfData.Item = append(rdfData.Item, item)
+			return nil
+		default:
+			return decoder.Skip()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rdfData.toRSSFeed(), nil
+}
+
+// decodeRDFChannel decodes the <channel> one child at a time, so namespaced
+// elements like <atom:link> don't end up in the channel's <link>
+func decodeRDFChannel(decoder *xml.Decoder, channel xml.StartElement, rdfData *RDFFeed) error {
+	rdfData.Channel.XMLBase = xmlBase(channel)
+	return eachChild(decoder, func(start xml.StartElement) error {
+		switch start.Name.Space {
+		case atomNamespace:
+			if start.Name.Local != "link" {
+				return decoder.Skip()
+			}
+			var link AtomLink
+			if err := decoder.DecodeElement(&link, &start); err != nil {
+				return err
+			}
+			rdfData.Channel.Links = append(rdfData.Channel.Links, link)
+			return nil
+		case syNamespace:
+			switch start.Name.Local {
+			case "updatePeriod":
+				return decoder.DecodeElement(&rdfData.Channel.UpdatePeriod, &start)
+			case "updateFrequency":
+				return decoder.DecodeElement(&rdfData.Channel.UpdateFrequency, &start)
+			}
+			return decoder.Skip()
+		}
+		switch start.Name.Local {
+		case "title":
+			return decoder.DecodeElement(&rdfData.Channel.Title, &start)
+		case "link":
+			return decoder.DecodeElement(&rdfData.Channel.Link, &start)
+		case "description":
+			return decoder.DecodeElement(&rdfData.Channel.Description, &start)
+		default:
+			return decoder.Skip()
+		}
+	})
+}
+
+// toRSSFeed normalizes the RSS 1.0 feed into the RSSFeed model used by the rest of gator
+func (f *RDFFeed) toRSSFeed() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = f.Channel.Title
+	feed.Channel.Link = f.Channel.Link
+	feed.Channel.Description = f.Channel.Description
+	feed.Channel.XMLBase = f.Channel.XMLBase
+	feed.Channel.UpdatePeriod = f.Channel.UpdatePeriod
+	feed.Channel.UpdateFrequency = f.Channel.UpdateFrequency
+	feed.Channel.Hub = relLink(f.Channel.Links, "hub")
+	feed.Channel.Self = relLink(f.Channel.Links, "self")
+	feed.Channel.Item = f.Item
+	return &feed
+}
@@ -0,0 +1,157 @@
+package rss
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// xmlBaseAttr is the xml:base attribute, which sets the url that relative
+// links inside the element are resolved against
+var xmlBaseAttr = xml.Name{Space: "http://www.w3.org/XML/1998/namespace", Local: "base"}
+
+// html attributes holding links that are rewritten in descriptions and content
+var htmlLinkAttrs = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"poster": true,
+}
+
+// xmlBase returns the xml:base declared on the element
+func xmlBase(start xml.StartElement) string {
+	for _, attr := range start.Attr {
+		if attr.Name == xmlBaseAttr {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+// joinXMLBase resolves the xml:base of an element against the one of its parent
+func joinXMLBase(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	if child == "" {
+		return parent
+	}
+	return resolveURL(parseBase(parent), child)
+}
+
+// parseBase parses a base url, returning nil when it is empty or invalid
+func parseBase(rawURL string) *url.URL {
+	if rawURL == "" {
+		return nil
+	}
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	return base
+}
+
+// withBase returns base with the xml:base ref applied on top of it
+func withBase(base *url.URL, ref string) *url.URL {
+	if ref == "" {
+		return base
+	}
+	if base == nil {
+		return parseBase(ref)
+	}
+	if resolved := parseBase(resolveURL(base, ref)); resolved != nil {
+		return resolved
+	}
+	return base
+}
+
+// resolveURL resolves ref against base, leaving it untouched when there is
+// no base or ref can't be parsed
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || ref == "" {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(refURL).String()
+}
+
+// resolveLinks makes the links of the feed absolute. Items are resolved against
+// their xml:base, then the channel xml:base, then the channel <link> and last
+// the url the feed was fetched from, which can be nil when it is unknown
+func resolveLinks(feed *RSSFeed, feedURL *url.URL) {
+	base := withBase(feedURL, feed.Channel.XMLBase)
+	feed.Channel.Link = resolveURL(base, feed.Channel.Link)
+	feed.Channel.Hub = resolveURL(base, feed.Channel.Hub)
+	feed.Channel.Self = resolveURL(base, feed.Channel.Self)
+
+	// relative item links are usually relative to the site rather than to
+	// the feed, unless the feed says otherwise with xml:base
+	itemsBase := base
+	if feed.Channel.XMLBase == "" {
+		if link := parseBase(feed.Channel.Link); link != nil && link.IsAbs() {
+			itemsBase = link
+		}
+	}
+	feed.Channel.XMLBase = ""
+
+	for i := range feed.Channel.Item {
+		item := &feed.Channel.Item[i]
+		itemBase := withBase(itemsBase, item.XMLBase)
+		item.XMLBase = ""
+
+		item.Link = resolveURL(itemBase, item.Link)
+		item.Description = resolveHTMLLinks(itemBase, item.Description)
+		item.Content = resolveHTMLLinks(itemBase, item.Content)
+		for j := range item.Enclosures {
+			item.Enclosures[j].URL = resolveURL(itemBase, item.Enclosures[j].URL)
+		}
+	}
+}
+
+// resolveHTMLLinks rewrites the href, src and poster attributes of an html
+// fragment against base. Tags without relative links are kept byte for byte
+func resolveHTMLLinks(base *url.URL, fragment string) string {
+	if base == nil || !strings.Contains(fragment, "<") {
+		return fragment
+	}
+
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	var out strings.Builder
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if !errors.Is(z.Err(), io.EOF) {
+				return fragment
+			}
+			return out.String()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := string(z.Raw())
+			token := z.Token()
+			changed := false
+			for i, attr := range token.Attr {
+				if attr.Namespace != "" || !htmlLinkAttrs[attr.Key] {
+					continue
+				}
+				if resolved := resolveURL(base, attr.Val); resolved != attr.Val {
+					token.Attr[i].Val = resolved
+					changed = true
+				}
+			}
+			if changed {
+				out.WriteString(token.String())
+			} else {
+				out.WriteString(raw)
+			}
+		default:
+			out.Write(z.Raw())
+		}
+	}
+}
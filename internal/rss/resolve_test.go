@@ -0,0 +1,130 @@
+package rss
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseFeed_ResolveLinks(t *testing.T) {
+	tests := []struct {
+		name                string
+		feedURL             string
+		input               string
+		expectedChannelLink string
+		expectedLink        string
+		expectedDescription string
+		expectedEnclosure   string
+	}{
+		{
+			name:    "relative to the channel link",
+			feedURL: "https://feeds.example.com/blog.xml",
+			input: `<rss><channel><title>Blog</title><link>https://example.com/blog/</link>
+<item><title>Post</title><link>/2024/05/post.html</link>
+<description>&lt;a href="related.html"&gt;Related&lt;/a&gt;</description>
+<enclosure url="audio/post.mp3" length="1" type="audio/mpeg"/></item></channel></rss>`,
+			expectedChannelLink: "https://example.com/blog/",
+			expectedLink:        "https://example.com/2024/05/post.html",
+			expectedDescription: `<a href="https://example.com/blog/related.html">Related</a>`,
+			expectedEnclosure:   "https://example.com/blog/audio/post.mp3",
+		},
+		{
+			name:    "relative channel link resolved against the feed url",
+			feedURL: "https://example.com/blog/feed.xml",
+			input: `<rss><channel><title>Blog</title><link>/blog</link>
+<item><title>Post</title><link>posts/1</link><description>No links</description></item></channel></rss>`,
+			expectedChannelLink: "https://example.com/blog",
+			expectedLink:        "https://example.com/posts/1",
+			expectedDescription: "No links",
+		},
+		{
+			name:    "no channel link falls back to the feed url",
+			feedURL: "https://example.com/blog/feed.xml",
+			input: `<rss><channel><title>Blog</title>
+<item><title>Post</title><link>posts/1</link></item></channel></rss>`,
+			expectedLink: "https://example.com/blog/posts/1",
+		},
+		{
+			name:    "item xml:base",
+			feedURL: "https://example.com/feed.xml",
+			input: `<rss><channel><title>Blog</title><link>https://example.com/</link>
+<item xml:base="https://cdn.example.org/articles/"><title>Post</title><link>post-1</link>
+<description>&lt;img src="cover.png" alt="cover"&gt;</description></item></channel></rss>`,
+			expectedChannelLink: "https://example.com/",
+			expectedLink:        "https://cdn.example.org/articles/post-1",
+			expectedDescription: `<img src="https://cdn.example.org/articles/cover.png" alt="cover">`,
+		},
+		{
+			name:    "channel xml:base wins over the channel link",
+			feedURL: "https://example.com/feed.xml",
+			input: `<rss><channel xml:base="https://example.com/archive/"><title>Blog</title><link>https://example.com/</link>
+<item><title>Post</title><link>post-1</link></item></channel></rss>`,
+			expectedChannelLink: "https://example.com/",
+			expectedLink:        "https://example.com/archive/post-1",
+		},
+		{
+			name:    "nested Atom xml:base",
+			feedURL: "https://example.com/feeds/atom.xml",
+			input: `<feed xmlns="http://www.w3.org/2005/Atom" xml:base="https://example.com/releases/">
+<title>Releases</title><link href="./"/>
+<entry xml:base="v1/"><title>v1</title><id>v1</id><link href="notes.html"/>
+<content type="html">&lt;a href="../v0/notes.html"&gt;previous&lt;/a&gt;</content></entry></feed>`,
+			expectedChannelLink: "https://example.com/releases/",
+			expectedLink:        "https://example.com/releases/v1/notes.html",
+			expectedDescription: `<a href="https://example.com/releases/v0/notes.html">previous</a>`,
+		},
+		{
+			name:    "absolute links are kept",
+			feedURL: "https://example.com/feed.xml",
+			input: `<rss><channel><title>Blog</title><link>https://example.com/</link>
+<item><title>Post</title><link>https://other.example.org/post</link>
+<description>&lt;p&gt;It&#39;s &lt;a href="https://other.example.org/" title="x"&gt;here&lt;/a&gt; &amp;amp; there&lt;/p&gt;</description></item></channel></rss>`,
+			expectedChannelLink: "https://example.com/",
+			expectedLink:        "https://other.example.org/post",
+			expectedDescription: `<p>It's <a href="https://other.example.org/" title="x">here</a> & there</p>`,
+		},
+		{
+			name:    "JSON Feed relative to the home page",
+			feedURL: "https://feeds.example.com/notes.json",
+			input: `{"version": "https://jsonfeed.org/version/1.1", "title": "Notes", "home_page_url": "https://example.com/notes/",
+"items": [{"id": "1", "url": "1.html", "summary": "<a href=\"/about\">About</a>"}]}`,
+			expectedChannelLink: "https://example.com/notes/",
+			expectedLink:        "https://example.com/notes/1.html",
+			expectedDescription: `<a href="https://example.com/about">About</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feedURL, err := url.Parse(tt.feedURL)
+			if err != nil {
+				t.Fatalf("invalid feed url: %v", err)
+			}
+
+			got, err := parseFeed(strings.NewReader(tt.input), feedURL, "", DefaultLimits)
+			if err != nil {
+				t.Fatalf("parseFeed() error = %v", err)
+			}
+			if len(got.Channel.Item) != 1 {
+				t.Fatalf("expected 1 item, got %d", len(got.Channel.Item))
+			}
+			item := got.Channel.Item[0]
+
+			if got.Channel.Link != tt.expectedChannelLink {
+				t.Errorf("expected channel link = %q, got %q", tt.expectedChannelLink, got.Channel.Link)
+			}
+			if item.Link != tt.expectedLink {
+				t.Errorf("expected link = %q, got %q", tt.expectedLink, item.Link)
+			}
+			if item.Description != tt.expectedDescription {
+				t.Errorf("expected description = %q, got %q", tt.expectedDescription, item.Description)
+			}
+			if tt.expectedEnclosure != "" && (len(item.Enclosures) != 1 || item.Enclosures[0].URL != tt.expectedEnclosure) {
+				t.Errorf("expected enclosure %q, got %+v", tt.expectedEnclosure, item.Enclosures)
+			}
+			if item.XMLBase != "" || got.Channel.XMLBase != "" {
+				t.Errorf("expected xml:base to be consumed, got channel %q and item %q", got.Channel.XMLBase, item.XMLBase)
+			}
+		})
+	}
+}
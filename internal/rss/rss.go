@@ -1,46 +1,115 @@
 package rss
 
 import (
+	"bufio"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"io"
+	"mime"
 	"net/http"
-	"time"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html/charset"
 )
 
 func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	client := &http.Client{
-		Timeout: 3 * time.Second,
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	result, err := DefaultClient.FetchFeedConditional(ctx, feedURL, CacheValidators{}, DefaultLimits)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't create request for RSS feed: %w", err)
+		return nil, err
 	}
+	return result.Feed, nil
+}
 
-	req.Header.Set("User-Agent", "Gator/1.0 (Linux; Custom Client)")
+// CacheValidators are the response headers used to ask the server for the
+// feed only when it changed since the last fetch
+type CacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// FetchResult is the outcome of a conditional fetch, Feed is nil when
+// NotModified is set. MovedTo is the url the feed permanently moved to when
+// the server answered with 301 or 308 redirects
+type FetchResult struct {
+	Feed        *RSSFeed
+	Validators  CacheValidators
+	NotModified bool
+	MovedTo     string
+}
+
+// FetchFeedConditional calls FetchFeedConditional on DefaultClient
+func FetchFeedConditional(ctx context.Context, feedURL string, validators CacheValidators, limits Limits) (*FetchResult, error) {
+	return DefaultClient.FetchFeedConditional(ctx, feedURL, validators, limits)
+}
 
-	resp, err := client.Do(req)
+// FetchFeedConditional reads the feed from the source its url points to,
+// sending the validators to sources that support conditional requests. A feed
+// that didn't change is reported as NotModified and keeps the validators that were sent
+func (c *Client) FetchFeedConditional(ctx context.Context, feedURL string, validators CacheValidators, limits Limits) (*FetchResult, error) {
+	source, err := c.NewSource(feedURL)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't get a response from the RSS feed: %w", err)
+		return nil, err
 	}
+	return source.Fetch(ctx, validators, limits)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+// permanentLocation returns the url reached through the permanent redirects
+// (301 and 308) at the start of the redirect chain of the response. It is
+// empty when the first redirect was temporary or there was no redirect
+func permanentLocation(resp *http.Response) string {
+	// each request made after a redirect keeps the response that caused it
+	var redirected []*http.Request
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		redirected = append(redirected, req)
 	}
 
-	defer resp.Body.Close()
-	rawXML, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't read response body from RSS feed response: %w", err)
+	location := ""
+	for i := len(redirected) - 1; i >= 0; i-- {
+		status := redirected[i].Response.StatusCode
+		if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+			break
+		}
+		location = redirected[i].URL.String()
 	}
+	return location
+}
 
-	var feedData RSSFeed
-	err = xml.Unmarshal(rawXML, &feedData)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't unmarshall raw XML data: %w", err)
+// limitBody caps the response body at MaxBodySize, failing early when the
+// server announces a larger Content-Length
+func limitBody(resp *http.Response, limits Limits) (io.Reader, error) {
+	if resp.ContentLength > limits.MaxBodySize {
+		return nil, fmt.Errorf("%w: Content-Length of %d bytes is larger than %d bytes", ErrMaxBodySize, resp.ContentLength, limits.MaxBodySize)
+	}
+	return newLimitedReader(resp.Body, limits.MaxBodySize), nil
+}
+
+// parseFeed detects the format of the feed from the Content-Type and the start
+// of the body and decodes it into the normalized RSSFeed model. Relative links
+// are resolved against feedURL, the final url the feed was fetched from
+func parseFeed(r io.Reader, feedURL *url.URL, contentType string, limits Limits) (*RSSFeed, error) {
+	limits = limits.withDefaults()
+
+	body := bufio.NewReader(r)
+	// a failed peek is reported by the decoders on their first read
+	head, _ := body.Peek(sniffLen)
+
+	var feedData *RSSFeed
+	if isJSONFeed(contentType, head) {
+		var err error
+		feedData, err = decodeJSONFeed(body, limits)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't unmarshall JSON feed data: %w", err)
+		}
+	} else {
+		var err error
+		feedData, err = parseXMLFeed(body, contentType, limits)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	feedData.Channel.Title = html.UnescapeString(feedData.Channel.Title)
@@ -50,7 +119,323 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 		item := &feedData.Channel.Item[i]
 		item.Title = html.UnescapeString(item.Title)
 		item.Description = html.UnescapeString(item.Description)
+		item.Content = strings.TrimSpace(item.Content)
+		item.Author = itemAuthor(*item)
+		if item.PubDate == "" {
+			item.PubDate = item.DCDate
+		}
+		item.Categories = normalizeCategories(append(item.Categories, item.DCSubjects...))
+		item.DCSubjects = nil
+	}
+	resolveLinks(feedData, feedURL)
+
+	return feedData, nil
+}
+
+// itemAuthor returns the author of an RSS item, from the first of <author>,
+// <dc:creator> and <itunes:author> it has. Podcasts often give the show as
+// <itunes:author>, so it is only used for items without another author
+func itemAuthor(item RSSItem) string {
+	for _, author := range []string{item.Author, item.DCCreator, item.ITunesAuthor} {
+		if author = cleanAuthor(html.UnescapeString(author)); author != "" {
+			return author
+		}
+	}
+	return ""
+}
+
+// cleanAuthor keeps the name of RSS 2.0 authors given as "email (Name)"
+func cleanAuthor(author string) string {
+	author = strings.TrimSpace(author)
+	if open := strings.Index(author, "("); open > 0 && strings.HasSuffix(author, ")") {
+		if name := strings.TrimSpace(author[open+1 : len(author)-1]); name != "" {
+			return name
+		}
+	}
+	return author
+}
+
+// normalizeCategories trims, unescapes and dedupes the categories of an item
+func normalizeCategories(categories []string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, category := range categories {
+		category = strings.TrimSpace(html.UnescapeString(category))
+		key := strings.ToLower(category)
+		if category == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, category)
 	}
+	return normalized
+}
+
+// number of bytes looked at to tell JSON and HTML documents apart from XML
+const sniffLen = 512
+
+// parseXMLFeed picks the XML feed format from the document's root element
+// and decodes it one element at a time
+func parseXMLFeed(r io.Reader, contentType string, limits Limits) (*RSSFeed, error) {
+	decoder, err := newXMLDecoder(r, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unmarshall raw XML data: %w", err)
+	}
+
+	root, err := rootElement(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unmarshall raw XML data: %w", err)
+	}
+
+	var feedData *RSSFeed
+	switch root.Name.Local {
+	case "rss":
+		feedData, err = decodeRSS(decoder, limits)
+	case "feed":
+		feedData, err = decodeAtom(decoder, limits)
+	case "RDF":
+		feedData, err = decodeRDF(decoder, limits)
+	default:
+		return nil, fmt.Errorf("unsupported feed format with root element <%s>", root.Name.Local)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unmarshall raw XML data: %w", err)
+	}
+	feedData.Channel.XMLBase = joinXMLBase(xmlBase(root), feedData.Channel.XMLBase)
+
+	return feedData, nil
+}
+
+// rootElement reads the document up to its first element
+func rootElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return xml.StartElement{}, errors.New("document has no root element")
+			}
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// eachChild calls fn for every child element of the element the decoder just
+// entered, until its end tag. fn must consume the child with DecodeElement or Skip
+func eachChild(decoder *xml.Decoder, fn func(start xml.StartElement) error) error {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := fn(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// decodeRSS decodes the <channel> of an RSS 2.0 document whose root was just read
+func decodeRSS(decoder *xml.Decoder, limits Limits) (*RSSFeed, error) {
+	var feed RSSFeed
+	err := eachChild(decoder, func(start xml.StartElement) error {
+		if start.Name.Local != "channel" {
+			return decoder.Skip()
+		}
+		feed.Channel.XMLBase = xmlBase(start)
+		return eachChild(decoder, func(start xml.StartElement) error {
+			if start.Name.Space == atomNamespace && start.Name.Local == "link" {
+				var link AtomLink
+				if err := decoder.DecodeElement(&link, &start); err != nil {
+					return err
+				}
+				feed.Channel.Hub = firstNonEmpty(feed.Channel.Hub, relLink([]AtomLink{link}, "hub"))
+				feed.Channel.Self = firstNonEmpty(feed.Channel.Self, relLink([]AtomLink{link}, "self"))
+				return nil
+			}
+			if start.Name.Space == syNamespace {
+				switch start.Name.Local {
+				case "updatePeriod":
+					return decoder.DecodeElement(&feed.Channel.UpdatePeriod, &start)
+				case "updateFrequency":
+					return decoder.DecodeElement(&feed.Channel.UpdateFrequency, &start)
+				}
+			}
+			// channel elements of other namespaces, like <atom:link>, are skipped
+			if start.Name.Space != "" {
+				return decoder.Skip()
+			}
+			switch start.Name.Local {
+			case "title":
+				return decoder.DecodeElement(&feed.Channel.Title, &start)
+			case "link":
+				return decoder.DecodeElement(&feed.Channel.Link, &start)
+			case "description":
+				return decoder.DecodeElement(&feed.Channel.Description, &start)
+			case "ttl":
+				return decoder.DecodeElement(&feed.Channel.TTL, &start)
+			case "skipHours":
+				var skip struct {
+					Hours []string `xml:"hour"`
+				}
+				if err := decoder.DecodeElement(&skip, &start); err != nil {
+					return err
+				}
+				feed.Channel.SkipHours = skip.Hours
+				return nil
+			case "skipDays":
+				var skip struct {
+					Days []string `xml:"day"`
+				}
+				if err := decoder.DecodeElement(&skip, &start); err != nil {
+					return err
+				}
+				feed.Channel.SkipDays = skip.Days
+				return nil
+			case "item":
+				if err := limits.checkItems(len(feed.Channel.Item)); err != nil {
+					return err
+				}
+				item, err := decodeItem(decoder, start)
+				if err != nil {
+					return err
+				}
+				feed.Channel.Item = append(feed.Channel.Item, item)
+				return nil
+			default:
+				return decoder.Skip()
+			}
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// Namespaces of the item elements gator reads besides the RSS ones
+const (
+	contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+	dcNamespace      = "http://purl.org/dc/elements/1.1/"
+	itunesNamespace  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+)
+
+// decodeItem decodes an RSS 2.0 or RSS 1.0 <item> one child at a time. Only
+// children in the namespace of the item itself are read as its title, link and
+// so on, so <itunes:title> or <atom:link> don't replace them
+func decodeItem(decoder *xml.Decoder, item xml.StartElement) (RSSItem, error) {
+	decoded := RSSItem{XMLBase: xmlBase(item)}
+	err := eachChild(decoder, func(start xml.StartElement) error {
+		switch start.Name.Space {
+		case item.Name.Space:
+			switch start.Name.Local {
+			case "title":
+				return decoder.DecodeElement(&decoded.Title, &start)
+			case "link":
+				return decoder.DecodeElement(&decoded.Link, &start)
+			case "guid":
+				return decoder.DecodeElement(&decoded.GUID, &start)
+			case "description":
+				return decoder.DecodeElement(&decoded.Description, &start)
+			case "author":
+				return decoder.DecodeElement(&decoded.Author, &start)
+			case "pubDate":
+				return decoder.DecodeElement(&decoded.PubDate, &start)
+			case "category":
+				var category string
+				if err := decoder.DecodeElement(&category, &start); err != nil {
+					return err
+				}
+				decoded.Categories = append(decoded.Categories, category)
+				return nil
+			case "enclosure":
+				var enclosure RSSEnclosure
+				if err := decoder.DecodeElement(&enclosure, &start); err != nil {
+					return err
+				}
+				decoded.Enclosures = append(decoded.Enclosures, enclosure)
+				return nil
+			}
+		case contentNamespace:
+			if start.Name.Local == "encoded" {
+				return decoder.DecodeElement(&decoded.Content, &start)
+			}
+		case dcNamespace:
+			switch start.Name.Local {
+			case "creator":
+				return decoder.DecodeElement(&decoded.DCCreator, &start)
+			case "date":
+				return decoder.DecodeElement(&decoded.DCDate, &start)
+			case "subject":
+				var subject string
+				if err := decoder.DecodeElement(&subject, &start); err != nil {
+					return err
+				}
+				decoded.DCSubjects = append(decoded.DCSubjects, subject)
+				return nil
+			}
+		case itunesNamespace:
+			switch start.Name.Local {
+			case "duration":
+				return decoder.DecodeElement(&decoded.Duration, &start)
+			case "episode":
+				return decoder.DecodeElement(&decoded.Episode, &start)
+			case "season":
+				return decoder.DecodeElement(&decoded.Season, &start)
+			case "episodeType":
+				return decoder.DecodeElement(&decoded.EpisodeType, &start)
+			case "author":
+				return decoder.DecodeElement(&decoded.ITunesAuthor, &start)
+			}
+		}
+		return decoder.Skip()
+	})
+	if err != nil {
+		return RSSItem{}, err
+	}
+	return decoded, nil
+}
+
+// newXMLDecoder returns a decoder that converts the document to UTF-8. A non
+// UTF-8 charset in the HTTP Content-Type takes precedence over the XML declaration,
+// otherwise the encoding named in the declaration is used
+func newXMLDecoder(input io.Reader, contentType string) (*xml.Decoder, error) {
+	transcoded := false
+	if label := contentTypeCharset(contentType); label != "" && !isUTF8(label) {
+		reader, err := charset.NewReaderLabel(label, input)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported charset %q: %w", label, err)
+		}
+		input = reader
+		transcoded = true
+	}
+
+	decoder := xml.NewDecoder(input)
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if transcoded {
+			return input, nil
+		}
+		return charset.NewReaderLabel(label, input)
+	}
+	return decoder, nil
+}
+
+// contentTypeCharset returns the charset parameter of a Content-Type header
+func contentTypeCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
 
-	return &feedData, nil
+func isUTF8(label string) bool {
+	label = strings.ToLower(label)
+	return label == "utf-8" || label == "utf8"
 }
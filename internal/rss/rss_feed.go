@@ -1,17 +1,77 @@
 package rss
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// RSSFeed is a parsed feed. RSS channels are decoded by decodeRSS, the other
+// formats are converted to it
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
-	} `xml:"channel"`
+		Title           string
+		Link            string
+		Description     string
+		XMLBase         string
+		TTL             string
+		UpdatePeriod    string
+		UpdateFrequency string
+		SkipHours       []string
+		SkipDays        []string
+		// Hub is the WebSub hub that pushes the feed's updates and Self the url
+		// the feed is published at, the topic to subscribe to at the hub
+		Hub  string
+		Self string
+		Item []RSSItem
+	}
 }
 
+// RSSItem is a feed item. RSS items are decoded by decodeItem, the other
+// formats are converted to it
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title        string
+	Link         string
+	GUID         string
+	Description  string
+	Content      string
+	Author       string
+	DCCreator    string
+	ITunesAuthor string
+	PubDate      string
+	DCDate       string
+	Categories   []string
+	DCSubjects   []string
+	Enclosures   []RSSEnclosure
+	XMLBase      string
+	ITunesItem
+}
+
+// Identity returns the value that tells this item apart from the other items
+// of its feed: the GUID, or the link for items without one. Items with neither
+// are identified by a hash of their content
+func (item RSSItem) Identity() string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	if link := strings.TrimSpace(item.Link); link != "" {
+		return link
+	}
+	sum := sha256.Sum256([]byte(item.Title + "\x00" + item.PubDate + "\x00" + item.Description))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// RSSEnclosure is a media file attached to an item, like a podcast episode
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// ITunesItem holds the episode metadata of the iTunes podcast namespace
+type ITunesItem struct {
+	Duration    string
+	Episode     string
+	Season      string
+	EpisodeType string
 }
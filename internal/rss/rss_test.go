@@ -1,40 +1,638 @@
 package rss
 
 import (
+	"bytes"
 	"context"
-	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
-// TODO: tests for FetchFeed
 func TestFetchFeed(t *testing.T) {
 	tests := []struct {
-		name         string
-		inputContext context.Context
-		inputFeed    string
-		expected     *RSSFeed
-		expectedErr  error
+		name          string
+		fixture       string
+		contentType   string
+		status        int
+		expectedTitle string
+		expectedItems int
+		expectError   bool
 	}{
 		{
-			name:         "test Fetch Feed output",
-			inputContext: context.Background(),
-			inputFeed:    "https://wagslane.dev/index.xml",
-			expected:     &RSSFeed{},
-			expectedErr:  nil,
+			name:          "fetch RSS 2.0 feed",
+			fixture:       "rss2.xml",
+			status:        http.StatusOK,
+			expectedTitle: "Gator Test Blog",
+			expectedItems: 2,
+		},
+		{
+			name:          "fetch Atom feed",
+			fixture:       "atom.xml",
+			status:        http.StatusOK,
+			expectedTitle: "Gator Releases",
+			expectedItems: 2,
+		},
+		{
+			name:          "fetch RSS 1.0 feed",
+			fixture:       "rdf.xml",
+			status:        http.StatusOK,
+			expectedTitle: "Gator Research Bulletin",
+			expectedItems: 2,
+		},
+		{
+			name:          "fetch JSON Feed",
+			fixture:       "jsonfeed.json",
+			contentType:   "application/feed+json; charset=utf-8",
+			status:        http.StatusOK,
+			expectedTitle: "Gator Notes",
+			expectedItems: 2,
+		},
+		{
+			name:        "unexpected HTTP status",
+			fixture:     "rss2.xml",
+			status:      http.StatusNotFound,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := readFixture(t, tt.fixture)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			got, err := FetchFeed(context.Background(), server.URL)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchFeed() error = %v", err)
+			}
+
+			if got.Channel.Title != tt.expectedTitle {
+				t.Errorf("expected title = %q, got %q", tt.expectedTitle, got.Channel.Title)
+			}
+			if len(got.Channel.Item) != tt.expectedItems {
+				t.Errorf("expected %d items, got %d", tt.expectedItems, len(got.Channel.Item))
+			}
+		})
+	}
+}
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name          string
+		fixture       string
+		contentType   string
+		expectedLink  string
+		expectedItems []RSSItem
+	}{
+		{
+			name:         "RSS 2.0",
+			fixture:      "rss2.xml",
+			expectedLink: "https://example.com/",
+			expectedItems: []RSSItem{
+				{
+					Title:       "First post",
+					Link:        "https://example.com/first",
+					GUID:        "first-post",
+					Description: "<p>Hello gators</p>",
+					Content:     "<p>Hello gators, this is the <em>whole</em> post.</p>",
+					Author:      "Ali Gator",
+					PubDate:     "Mon, 06 May 2024 10:00:00 +0000",
+					Categories:  []string{"Go", "Reptiles"},
+				},
+				{
+					Title:       "Second post",
+					Link:        "https://example.com/second",
+					Description: "Another one",
+					Author:      "Croc O'Dile",
+					DCCreator:   "Croc O'Dile",
+					PubDate:     "Tue, 07 May 2024 10:00:00 +0000",
+				},
+			},
+		},
+		{
+			name:         "Atom 1.0",
+			fixture:      "atom.xml",
+			expectedLink: "https://example.com/releases",
+			expectedItems: []RSSItem{
+				{
+					Title:       "v1.1.0",
+					Link:        "https://example.com/releases/v1.1.0",
+					GUID:        "tag:example.com,2024:v1.1.0",
+					Description: "<p>Atom support</p>",
+					Author:      "Gator Team",
+					PubDate:     "2024-05-07T10:00:00Z",
+					Categories:  []string{"releases"},
+				},
+				{
+					Title:       "v1.0.0",
+					Link:        "https://example.com/releases/v1.0.0",
+					GUID:        "tag:example.com,2024:v1.0.0",
+					Description: `<div xmlns="http://www.w3.org/1999/xhtml"><p>First release</p></div>`,
+					Content:     `<div xmlns="http://www.w3.org/1999/xhtml"><p>First release</p></div>`,
+					Author:      "release-bot@example.com",
+					PubDate:     "2024-05-01T10:00:00Z",
+				},
+			},
+		},
+		{
+			name:         "RSS 2.0 podcast",
+			fixture:      "podcast.xml",
+			expectedLink: "https://example.com/podcast",
+			expectedItems: []RSSItem{
+				{
+					Title:       "Episode 12: Cold blood",
+					Link:        "https://example.com/podcast/12",
+					Description: "Thermoregulation explained",
+					// <dc:creator> is preferred to <itunes:author>
+					Author:       "Ali Gator",
+					DCCreator:    "Ali Gator",
+					ITunesAuthor: "Gator Talk",
+					PubDate:      "Mon, 06 May 2024 10:00:00 +0000",
+					Enclosures: []RSSEnclosure{
+						{URL: "https://cdn.example.com/ep12.mp3", Length: "24986239", Type: "audio/mpeg"},
+					},
+					ITunesItem: ITunesItem{
+						Duration:    "41:38",
+						Episode:     "12",
+						Season:      "2",
+						EpisodeType: "full",
+					},
+				},
+				{
+					// <itunes:title> and <atom:link> are not taken for the
+					// item's own elements, <itunes:author> is the only author
+					Title:        "Episode 11: Basking in the sun",
+					Link:         "https://example.com/podcast/11",
+					GUID:         "gator-talk-11",
+					Description:  "Why gators lie in the sun",
+					Author:       "Gator Talk",
+					ITunesAuthor: "Gator Talk",
+					PubDate:      "Mon, 29 Apr 2024 10:00:00 +0000",
+					Enclosures: []RSSEnclosure{
+						{URL: "https://cdn.example.com/ep11.mp3", Length: "21337012", Type: "audio/mpeg"},
+					},
+					ITunesItem: ITunesItem{
+						Duration:    "35:02",
+						Episode:     "11",
+						Season:      "2",
+						EpisodeType: "full",
+					},
+				},
+			},
+		},
+		{
+			name:         "RSS 1.0 (RDF)",
+			fixture:      "rdf.xml",
+			expectedLink: "https://example.gov/news",
+			expectedItems: []RSSItem{
+				{
+					Title:       "Nesting season report",
+					Link:        "https://example.gov/news/nesting",
+					Description: "Nest counts are up",
+					Author:      "Field Team",
+					DCCreator:   "Field Team",
+					PubDate:     "2024-05-07T10:00:00+00:00",
+					DCDate:      "2024-05-07T10:00:00+00:00",
+					Categories:  []string{"Wildlife"},
+				},
+				{
+					Title:   "Annual census",
+					Link:    "https://example.gov/news/census",
+					PubDate: "2024-04-01",
+					DCDate:  "2024-04-01",
+				},
+			},
+		},
+		{
+			name:         "JSON Feed detected from Content-Type",
+			fixture:      "jsonfeed.json",
+			contentType:  "application/feed+json",
+			expectedLink: "https://example.com/notes",
+			expectedItems: []RSSItem{
+				{
+					Title:       "JSON Feed support",
+					Link:        "https://example.com/notes/2",
+					GUID:        "2",
+					Description: "<p>Now with JSON</p>",
+					Content:     "<p>Now with JSON</p>",
+					Author:      "Ali Gator",
+					PubDate:     "2024-05-07T10:00:00Z",
+					Categories:  []string{"json", "feeds"},
+				},
+				{
+					Title:       "Linked article",
+					Link:        "https://other.example.org/article",
+					GUID:        "1",
+					Description: "Worth a read",
+					Content:     "Worth a read, all of it",
+					Author:      "https://other.example.org/about",
+					PubDate:     "2024-05-01T10:00:00-05:00",
+				},
+			},
+		},
+		{
+			name:         "JSON Feed detected from body",
+			fixture:      "jsonfeed.json",
+			contentType:  "text/plain",
+			expectedLink: "https://example.com/notes",
+			expectedItems: []RSSItem{
+				{
+					Title:       "JSON Feed support",
+					Link:        "https://example.com/notes/2",
+					GUID:        "2",
+					Description: "<p>Now with JSON</p>",
+					Content:     "<p>Now with JSON</p>",
+					Author:      "Ali Gator",
+					PubDate:     "2024-05-07T10:00:00Z",
+					Categories:  []string{"json", "feeds"},
+				},
+				{
+					Title:       "Linked article",
+					Link:        "https://other.example.org/article",
+					GUID:        "1",
+					Description: "Worth a read",
+					Content:     "Worth a read, all of it",
+					Author:      "https://other.example.org/about",
+					PubDate:     "2024-05-01T10:00:00-05:00",
+				},
+			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := FetchFeed(tt.inputContext, tt.inputFeed)
-			fmt.Printf("%v", got)
+			got, err := parseFeed(bytes.NewReader(readFixture(t, tt.fixture)), nil, tt.contentType, DefaultLimits)
+			if err != nil {
+				t.Fatalf("parseFeed() error = %v", err)
+			}
 
-			if got != tt.expected {
-				t.Errorf("not working %v", err)
+			if got.Channel.Link != tt.expectedLink {
+				t.Errorf("expected channel link = %q, got %q", tt.expectedLink, got.Channel.Link)
 			}
+			if len(got.Channel.Item) != len(tt.expectedItems) {
+				t.Fatalf("expected %d items, got %d", len(tt.expectedItems), len(got.Channel.Item))
+			}
+			for i, expected := range tt.expectedItems {
+				if !reflect.DeepEqual(got.Channel.Item[i], expected) {
+					t.Errorf("item %d: expected %+v, got %+v", i, expected, got.Channel.Item[i])
+				}
+			}
+		})
+	}
+}
 
-			if err != tt.expectedErr {
-				t.Errorf("expected nil %v", err)
+func TestParseFeed_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contentType string
+		errorMsg    string
+	}{
+		{
+			name:     "empty document",
+			input:    "",
+			errorMsg: "no root element",
+		},
+		{
+			name:     "unknown root element",
+			input:    "<html><body></body></html>",
+			errorMsg: "unsupported feed format",
+		},
+		{
+			name:     "malformed XML",
+			input:    "<rss><channel><title>broken</channel></rss>",
+			errorMsg: "couldn't unmarshall raw XML data",
+		},
+		{
+			name:        "malformed JSON Feed",
+			input:       `{"title": "broken"`,
+			contentType: "application/feed+json",
+			errorMsg:    "couldn't unmarshall JSON feed data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFeed(strings.NewReader(tt.input), nil, tt.contentType, DefaultLimits)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error to contain %q, got %q", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func TestFetchFeedConditional(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Tue, 07 May 2024 10:00:00 GMT"
+
+	tests := []struct {
+		name                string
+		validators          CacheValidators
+		expectedNotModified bool
+		expectedValidators  CacheValidators
+	}{
+		{
+			name:                "first fetch stores validators",
+			validators:          CacheValidators{},
+			expectedNotModified: false,
+			expectedValidators:  CacheValidators{ETag: etag, LastModified: lastModified},
+		},
+		{
+			name:                "matching etag is not modified",
+			validators:          CacheValidators{ETag: etag},
+			expectedNotModified: true,
+			expectedValidators:  CacheValidators{ETag: etag},
+		},
+		{
+			name:                "matching last modified is not modified",
+			validators:          CacheValidators{LastModified: lastModified},
+			expectedNotModified: true,
+			expectedValidators:  CacheValidators{LastModified: lastModified},
+		},
+		{
+			name:                "stale etag fetches the feed again",
+			validators:          CacheValidators{ETag: `"v0"`},
+			expectedNotModified: false,
+			expectedValidators:  CacheValidators{ETag: etag, LastModified: lastModified},
+		},
+	}
+
+	body := readFixture(t, "rss2.xml")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag || r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchFeedConditional(context.Background(), server.URL, tt.validators, DefaultLimits)
+			if err != nil {
+				t.Fatalf("FetchFeedConditional() error = %v", err)
+			}
+
+			if got.NotModified != tt.expectedNotModified {
+				t.Errorf("expected NotModified = %v, got %v", tt.expectedNotModified, got.NotModified)
+			}
+			if got.NotModified && got.Feed != nil {
+				t.Errorf("expected no feed on a 304 response")
+			}
+			if !got.NotModified && len(got.Feed.Channel.Item) != 2 {
+				t.Errorf("expected 2 items, got %d", len(got.Feed.Channel.Item))
+			}
+			if got.Validators != tt.expectedValidators {
+				t.Errorf("expected validators %+v, got %+v", tt.expectedValidators, got.Validators)
+			}
+		})
+	}
+}
+
+func TestFetchFeedConditional_Redirects(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		expectedMovedTo string
+	}{
+		{
+			name: "no redirect",
+			path: "/feed",
+		},
+		{
+			name:            "moved permanently",
+			path:            "/301",
+			expectedMovedTo: "/feed",
+		},
+		{
+			name:            "permanent redirect",
+			path:            "/308",
+			expectedMovedTo: "/feed",
+		},
+		{
+			name: "found",
+			path: "/302",
+		},
+		{
+			name: "temporary redirect",
+			path: "/307",
+		},
+		{
+			name:            "permanent chain",
+			path:            "/301-to-308",
+			expectedMovedTo: "/feed",
+		},
+		{
+			name:            "permanent then temporary keeps the permanent target",
+			path:            "/301-to-302",
+			expectedMovedTo: "/302",
+		},
+		{
+			name: "temporary then permanent",
+			path: "/302-to-301",
+		},
+	}
+
+	body := readFixture(t, "rss2.xml")
+	redirects := map[string]struct {
+		status   int
+		location string
+	}{
+		"/301":        {http.StatusMovedPermanently, "/feed"},
+		"/308":        {http.StatusPermanentRedirect, "/feed"},
+		"/302":        {http.StatusFound, "/feed"},
+		"/307":        {http.StatusTemporaryRedirect, "/feed"},
+		"/301-to-308": {http.StatusMovedPermanently, "/308"},
+		"/301-to-302": {http.StatusMovedPermanently, "/302"},
+		"/302-to-301": {http.StatusFound, "/301"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if redirect, ok := redirects[r.URL.Path]; ok {
+			http.Redirect(w, r, redirect.location, redirect.status)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchFeedConditional(context.Background(), server.URL+tt.path, CacheValidators{}, DefaultLimits)
+			if err != nil {
+				t.Fatalf("FetchFeedConditional() error = %v", err)
+			}
+
+			expected := ""
+			if tt.expectedMovedTo != "" {
+				expected = server.URL + tt.expectedMovedTo
+			}
+			if got.MovedTo != expected {
+				t.Errorf("expected MovedTo = %q, got %q", expected, got.MovedTo)
+			}
+		})
+	}
+}
+
+func TestParseFeed_Charsets(t *testing.T) {
+	tests := []struct {
+		name          string
+		fixture       string
+		contentType   string
+		expectedTitle string
+		expectedItem  string
+	}{
+		{
+			name:          "ISO-8859-1 declaration",
+			fixture:       "iso-8859-1.xml",
+			contentType:   "application/rss+xml",
+			expectedTitle: "Café del Caimán",
+			expectedItem:  "Niño y caimán",
+		},
+		{
+			name:          "windows-1252 declaration",
+			fixture:       "windows-1252.xml",
+			contentType:   "text/xml",
+			expectedTitle: "Gator “Quotes”",
+			expectedItem:  "It’s a gator",
+		},
+		{
+			name:          "Shift_JIS declaration",
+			fixture:       "shift_jis.xml",
+			expectedTitle: "ワニのブログ",
+			expectedItem:  "ワニの記事",
+		},
+		{
+			name:          "charset only in Content-Type",
+			fixture:       "latin1-nodecl.xml",
+			contentType:   "application/rss+xml; charset=ISO-8859-1",
+			expectedTitle: "Café del Caimán",
+			expectedItem:  "Niño y caimán",
+		},
+		{
+			name:          "Content-Type charset takes precedence over declaration",
+			fixture:       "windows-1252.xml",
+			contentType:   "text/xml; charset=windows-1252",
+			expectedTitle: "Gator “Quotes”",
+			expectedItem:  "It’s a gator",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFeed(bytes.NewReader(readFixture(t, tt.fixture)), nil, tt.contentType, DefaultLimits)
+			if err != nil {
+				t.Fatalf("parseFeed() error = %v", err)
+			}
+
+			if got.Channel.Title != tt.expectedTitle {
+				t.Errorf("expected title = %q, got %q", tt.expectedTitle, got.Channel.Title)
+			}
+			if len(got.Channel.Item) != 1 || got.Channel.Item[0].Title != tt.expectedItem {
+				t.Errorf("expected one item titled %q, got %+v", tt.expectedItem, got.Channel.Item)
+			}
+		})
+	}
+}
+
+func TestRSSItem_Identity(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     RSSItem
+		expected string
+	}{
+		{
+			name:     "GUID takes precedence over the link",
+			item:     RSSItem{GUID: " tag:example.com,2024:1 ", Link: "https://example.com/1"},
+			expected: "tag:example.com,2024:1",
+		},
+		{
+			name:     "link is used when there is no GUID",
+			item:     RSSItem{Link: "https://example.com/1"},
+			expected: "https://example.com/1",
+		},
+		{
+			name:     "content hash is used when there is neither",
+			item:     RSSItem{Title: "Untitled note"},
+			expected: "sha256:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.item.Identity()
+			if !strings.HasPrefix(got, tt.expected) {
+				t.Errorf("expected identity %q, got %q", tt.expected, got)
+			}
+			if got != tt.item.Identity() {
+				t.Errorf("expected identity to be stable")
+			}
+		})
+	}
+}
+
+func TestItemAuthor(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     RSSItem
+		expected string
+	}{
+		{
+			name:     "author takes precedence",
+			item:     RSSItem{Author: "ali@example.com (Ali Gator)", DCCreator: "Croc O'Dile", ITunesAuthor: "Gator Talk"},
+			expected: "Ali Gator",
+		},
+		{
+			name:     "dc:creator takes precedence over itunes:author",
+			item:     RSSItem{DCCreator: "Croc O&#39;Dile", ITunesAuthor: "Gator Talk"},
+			expected: "Croc O'Dile",
+		},
+		{
+			name:     "itunes:author is used without another author",
+			item:     RSSItem{Author: " ", ITunesAuthor: "Gator Talk"},
+			expected: "Gator Talk",
+		},
+		{
+			name: "no author",
+			item: RSSItem{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := itemAuthor(tt.item); got != tt.expected {
+				t.Errorf("expected author %q, got %q", tt.expected, got)
 			}
 		})
 	}
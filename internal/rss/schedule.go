@@ -0,0 +1,110 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syNamespace is the RSS syndication module, which declares how often a feed is updated
+const syNamespace = "http://purl.org/rss/1.0/modules/syndication/"
+
+// maxScheduleInterval caps the interval a feed can ask for, so a feed that
+// claims to update yearly is still checked every week
+const maxScheduleInterval = 7 * 24 * time.Hour
+
+// Schedule is when the feed says it should be fetched again
+type Schedule struct {
+	// Interval is the time to wait between fetches, zero when the feed doesn't say
+	Interval time.Duration
+	// SkipHours are the hours of the day (0-23, in GMT) the feed shouldn't be fetched
+	SkipHours map[int]bool
+	// SkipDays are the days of the week the feed shouldn't be fetched
+	SkipDays map[time.Weekday]bool
+}
+
+var syUpdatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Schedule reads <ttl>, sy:updatePeriod, sy:updateFrequency, <skipHours> and
+// <skipDays> from the channel. When both ttl and the syndication module are
+// present the longer interval is used. Invalid values are ignored
+func (f *RSSFeed) Schedule() Schedule {
+	channel := f.Channel
+	var schedule Schedule
+
+	if ttl, err := strconv.Atoi(strings.TrimSpace(channel.TTL)); err == nil && ttl > 0 {
+		schedule.Interval = time.Duration(ttl) * time.Minute
+	}
+
+	if channel.UpdatePeriod != "" || channel.UpdateFrequency != "" {
+		period, ok := syUpdatePeriods[strings.ToLower(strings.TrimSpace(channel.UpdatePeriod))]
+		if channel.UpdatePeriod == "" {
+			period, ok = syUpdatePeriods["daily"], true
+		}
+		frequency := 1
+		if channel.UpdateFrequency != "" {
+			if n, err := strconv.Atoi(strings.TrimSpace(channel.UpdateFrequency)); err == nil && n > 0 {
+				frequency = n
+			}
+		}
+		if ok {
+			schedule.Interval = max(schedule.Interval, period/time.Duration(frequency))
+		}
+	}
+	schedule.Interval = min(schedule.Interval, maxScheduleInterval)
+
+	for _, hour := range channel.SkipHours {
+		// hour 24 is sometimes used for midnight
+		if h, err := strconv.Atoi(strings.TrimSpace(hour)); err == nil && h >= 0 && h <= 24 {
+			if schedule.SkipHours == nil {
+				schedule.SkipHours = map[int]bool{}
+			}
+			schedule.SkipHours[h%24] = true
+		}
+	}
+	for _, day := range channel.SkipDays {
+		if weekday, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]; ok {
+			if schedule.SkipDays == nil {
+				schedule.SkipDays = map[time.Weekday]bool{}
+			}
+			schedule.SkipDays[weekday] = true
+		}
+	}
+
+	return schedule
+}
+
+// NextFetch returns when the feed is due again after a fetch at from. The time
+// is moved forward to the first hour that isn't skipped, skip rules that leave
+// no hour of the week to fetch in are ignored
+func (s Schedule) NextFetch(from time.Time) time.Time {
+	next := from.UTC().Add(s.Interval)
+	candidate := next
+	for range 7 * 24 {
+		if !s.skipped(candidate) {
+			return candidate
+		}
+		candidate = candidate.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next
+}
+
+func (s Schedule) skipped(t time.Time) bool {
+	return s.SkipHours[t.Hour()] || s.SkipDays[t.Weekday()]
+}
@@ -0,0 +1,104 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRSSFeed_Schedule(t *testing.T) {
+	// a Monday, 10:30 GMT
+	from := time.Date(2024, 5, 6, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		input            string
+		expectedInterval time.Duration
+		expectedNext     time.Time
+	}{
+		{
+			name:             "no hints",
+			input:            `<rss><channel><title>Blog</title></channel></rss>`,
+			expectedInterval: 0,
+			expectedNext:     from,
+		},
+		{
+			name:             "ttl in minutes",
+			input:            `<rss><channel><title>Blog</title><ttl>90</ttl></channel></rss>`,
+			expectedInterval: 90 * time.Minute,
+			expectedNext:     from.Add(90 * time.Minute),
+		},
+		{
+			name: "syndication module",
+			input: `<rss xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><channel><title>Blog</title>
+<sy:updatePeriod>daily</sy:updatePeriod><sy:updateFrequency>4</sy:updateFrequency></channel></rss>`,
+			expectedInterval: 6 * time.Hour,
+			expectedNext:     from.Add(6 * time.Hour),
+		},
+		{
+			name: "longest of ttl and syndication module",
+			input: `<rss xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><channel><title>Blog</title>
+<ttl>60</ttl><sy:updatePeriod>hourly</sy:updatePeriod><sy:updateFrequency>1</sy:updateFrequency></channel></rss>`,
+			expectedInterval: time.Hour,
+			expectedNext:     from.Add(time.Hour),
+		},
+		{
+			name: "RSS 1.0 syndication module",
+			input: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/" xmlns="http://purl.org/rss/1.0/">
+<channel><title>Bulletin</title><sy:updatePeriod>weekly</sy:updatePeriod></channel></rdf:RDF>`,
+			expectedInterval: 7 * 24 * time.Hour,
+			expectedNext:     from.Add(7 * 24 * time.Hour),
+		},
+		{
+			name:             "interval is capped",
+			input:            `<rss><channel><title>Blog</title><ttl>1000000</ttl></channel></rss>`,
+			expectedInterval: maxScheduleInterval,
+			expectedNext:     from.Add(maxScheduleInterval),
+		},
+		{
+			name:             "invalid values are ignored",
+			input:            `<rss><channel><title>Blog</title><ttl>soon</ttl><skipHours><hour>25</hour></skipHours><skipDays><day>Someday</day></skipDays></channel></rss>`,
+			expectedInterval: 0,
+			expectedNext:     from,
+		},
+		{
+			name: "skipped hours",
+			input: `<rss><channel><title>Blog</title><ttl>30</ttl>
+<skipHours><hour>11</hour><hour>12</hour></skipHours></channel></rss>`,
+			expectedInterval: 30 * time.Minute,
+			expectedNext:     time.Date(2024, 5, 6, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "skipped days",
+			input: `<rss><channel><title>Blog</title><ttl>1440</ttl>
+<skipDays><day>Tuesday</day><day>Wednesday</day></skipDays></channel></rss>`,
+			expectedInterval: 24 * time.Hour,
+			expectedNext:     time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every hour skipped",
+			input: `<rss><channel><title>Blog</title><ttl>60</ttl><skipDays>
+<day>Monday</day><day>Tuesday</day><day>Wednesday</day><day>Thursday</day><day>Friday</day><day>Saturday</day><day>Sunday</day>
+</skipDays></channel></rss>`,
+			expectedInterval: time.Hour,
+			expectedNext:     from.Add(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := parseFeed(strings.NewReader(tt.input), nil, "", DefaultLimits)
+			if err != nil {
+				t.Fatalf("parseFeed() error = %v", err)
+			}
+
+			schedule := feed.Schedule()
+			if schedule.Interval != tt.expectedInterval {
+				t.Errorf("expected interval %v, got %v", tt.expectedInterval, schedule.Interval)
+			}
+			if next := schedule.NextFetch(from); !next.Equal(tt.expectedNext) {
+				t.Errorf("expected next fetch at %v, got %v", tt.expectedNext, next)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package rss
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ManoloEsS/gator_cli/internal/selector"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// Selectors pick the items out of a page that has no feed. Item matches the
+// element of every item, the other selectors are matched inside it
+type Selectors struct {
+	Item  string `json:"item"`
+	Title string `json:"title"`
+	// Link is optional, the first link of the item is used when it is empty
+	Link string `json:"link,omitempty"`
+	// Date is optional, items without one are dated when they are fetched
+	Date string `json:"date,omitempty"`
+}
+
+type compiledSelectors struct {
+	item, title, link, date selector.Selector
+}
+
+var firstLink = func() selector.Selector {
+	sel, _ := selector.Parse("a[href]")
+	return sel
+}()
+
+// Validate reports whether the selectors can be parsed, Item and Title are required
+func (s Selectors) Validate() error {
+	_, err := s.compile()
+	return err
+}
+
+func (s Selectors) compile() (compiledSelectors, error) {
+	if strings.TrimSpace(s.Item) == "" || strings.TrimSpace(s.Title) == "" {
+		return compiledSelectors{}, errors.New("item and title selectors are required")
+	}
+
+	compiled := compiledSelectors{link: firstLink}
+	fields := []struct {
+		value string
+		dest  *selector.Selector
+	}{
+		{s.Item, &compiled.item},
+		{s.Title, &compiled.title},
+		{s.Link, &compiled.link},
+		{s.Date, &compiled.date},
+	}
+	for _, field := range fields {
+		if strings.TrimSpace(field.value) == "" {
+			continue
+		}
+		sel, err := selector.Parse(field.value)
+		if err != nil {
+			return compiledSelectors{}, err
+		}
+		*field.dest = sel
+	}
+	return compiled, nil
+}
+
+// scrapeAdapter scrapes the page at the url with the selectors of the config
+func scrapeAdapter(c *Client, pageURL string, config json.RawMessage) (Source, error) {
+	var selectors Selectors
+	if err := decodeConfig(config, &selectors); err != nil {
+		return nil, err
+	}
+	if err := selectors.Validate(); err != nil {
+		return nil, err
+	}
+	return ScrapeSource{URL: pageURL, Selectors: selectors, Client: c}, nil
+}
+
+// ScrapeSource builds the feed of an HTML page without one, every element
+// matched by the item selector is an item. A nil Client uses DefaultClient
+type ScrapeSource struct {
+	URL       string
+	Selectors Selectors
+	Client    *Client
+}
+
+func (src ScrapeSource) Fetch(ctx context.Context, validators CacheValidators, limits Limits) (*FetchResult, error) {
+	limits = limits.withDefaults()
+	selectors, err := src.Selectors.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	if validators.ETag != "" {
+		header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	client := src.Client
+	if client == nil {
+		client = DefaultClient
+	}
+	resp, err := client.get(ctx, src.URL, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &FetchResult{
+			Validators:  validators,
+			NotModified: true,
+			MovedTo:     permanentLocation(resp),
+		}, nil
+	}
+
+	body, err := limitBody(resp, limits)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := charset.NewReader(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read page: %w", err)
+	}
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse page: %w", err)
+	}
+
+	feedData, err := scrapeItems(doc, selectors, limits)
+	if err != nil {
+		return nil, err
+	}
+	feedData.Channel.Link = resp.Request.URL.String()
+	resolveLinks(feedData, resp.Request.URL)
+
+	return &FetchResult{
+		Feed: feedData,
+		Validators: CacheValidators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+		MovedTo: permanentLocation(resp),
+	}, nil
+}
+
+// scrapeItems turns the elements matched by the item selector into feed
+// items, elements without a title or a link are skipped
+func scrapeItems(doc *html.Node, selectors compiledSelectors, limits Limits) (*RSSFeed, error) {
+	var feed RSSFeed
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.Data {
+		case "title":
+			if feed.Channel.Title == "" {
+				feed.Channel.Title = nodeText(n)
+			}
+		case "base":
+			if feed.Channel.XMLBase == "" {
+				feed.Channel.XMLBase = strings.TrimSpace(nodeAttr(n, "href"))
+			}
+		}
+	}
+
+	for _, element := range selectors.item.All(doc) {
+		item := RSSItem{
+			Title:       nodeText(selectors.title.First(element)),
+			Link:        itemLink(element, selectors.link),
+			Description: innerHTML(element),
+		}
+		if item.Title == "" && item.Link == "" {
+			continue
+		}
+		if date := selectors.date.First(element); date != nil {
+			item.PubDate = firstNonEmpty(nodeAttr(date, "datetime"), nodeAttr(date, "content"), nodeText(date))
+		}
+
+		if err := limits.checkItems(len(feed.Channel.Item)); err != nil {
+			return nil, err
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return &feed, nil
+}
+
+// itemLink returns the href of the element matched by the link selector, or
+// of the first link inside it. Items that are links themselves use their href
+func itemLink(item *html.Node, link selector.Selector) string {
+	n := link.First(item)
+	if n == nil {
+		return strings.TrimSpace(nodeAttr(item, "href"))
+	}
+	if href := nodeAttr(n, "href"); href != "" {
+		return strings.TrimSpace(href)
+	}
+	if inner := firstLink.First(n); inner != nil {
+		return strings.TrimSpace(nodeAttr(inner, "href"))
+	}
+	return ""
+}
+
+// nodeText returns the text inside the element with its spaces collapsed
+func nodeText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	for child := range n.Descendants() {
+		if child.Type == html.TextNode {
+			b.WriteString(child.Data)
+			b.WriteString(" ")
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func nodeAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func innerHTML(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&b, child); err != nil {
+			return ""
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
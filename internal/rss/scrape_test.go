@@ -0,0 +1,135 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const scrapePage = `<html><head><title>Swamp News</title><base href="/news/"></head><body>
+<nav><a href="/">Home</a></nav>
+<ul class="items">
+  <li class="item"><h3>Gators <em>spotted</em> downtown</h3><a class="more" href="gators">Read more</a><span class="date">Mon, 06 Jan 2025 10:00:00 GMT</span></li>
+  <li class="item"><h3>Heron season opens</h3><a class="more" href="https://birds.example.com/herons">Read more</a><time datetime="2025-01-05T08:00:00Z">Sunday</time></li>
+  <li class="item"><p>No title or link here</p></li>
+</ul>
+</body></html>`
+
+func TestScrapeSource(t *testing.T) {
+	tests := []struct {
+		name          string
+		selectors     Selectors
+		expectedItems []RSSItem
+		expectedErr   error
+		expectError   bool
+	}{
+		{
+			name:      "all selectors",
+			selectors: Selectors{Item: "li.item", Title: "h3", Link: "a.more", Date: ".date, time"},
+			expectedItems: []RSSItem{
+				{Title: "Gators spotted downtown", Link: "/news/gators", PubDate: "Mon, 06 Jan 2025 10:00:00 GMT"},
+				{Title: "Heron season opens", Link: "https://birds.example.com/herons", PubDate: "2025-01-05T08:00:00Z"},
+			},
+		},
+		{
+			name:      "first link and no date",
+			selectors: Selectors{Item: ".items > li", Title: "h3"},
+			expectedItems: []RSSItem{
+				{Title: "Gators spotted downtown", Link: "/news/gators"},
+				{Title: "Heron season opens", Link: "https://birds.example.com/herons"},
+			},
+		},
+		{
+			name:      "items that are links",
+			selectors: Selectors{Item: "a.more", Title: "em"},
+			expectedItems: []RSSItem{
+				{Link: "/news/gators"},
+				{Link: "https://birds.example.com/herons"},
+			},
+		},
+		{
+			name:          "nothing matches",
+			selectors:     Selectors{Item: "article", Title: "h2"},
+			expectedItems: nil,
+		},
+		{
+			name:        "missing title selector",
+			selectors:   Selectors{Item: "li.item"},
+			expectError: true,
+		},
+		{
+			name:        "invalid selector",
+			selectors:   Selectors{Item: "li.item", Title: "h3:first-child"},
+			expectError: true,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(scrapePage))
+	}))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := ScrapeSource{URL: server.URL + "/news/", Selectors: tt.selectors}
+			result, err := source.Fetch(context.Background(), CacheValidators{}, DefaultLimits)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			channel := result.Feed.Channel
+			if channel.Title != "Swamp News" || channel.Link != server.URL+"/news/" {
+				t.Errorf("expected the page title and url, got %q %q", channel.Title, channel.Link)
+			}
+			if result.Validators.ETag != `"v1"` {
+				t.Errorf("expected the ETag to be kept, got %q", result.Validators.ETag)
+			}
+			if len(channel.Item) != len(tt.expectedItems) {
+				t.Fatalf("expected %d items, got %d", len(tt.expectedItems), len(channel.Item))
+			}
+			for i, expected := range tt.expectedItems {
+				got := channel.Item[i]
+				if strings.HasPrefix(expected.Link, "/") {
+					expected.Link = server.URL + expected.Link
+				}
+				if got.Title != expected.Title || got.Link != expected.Link || got.PubDate != expected.PubDate {
+					t.Errorf("expected item %+v, got %+v", expected, got)
+				}
+			}
+		})
+	}
+
+	t.Run("not modified", func(t *testing.T) {
+		source := ScrapeSource{URL: server.URL, Selectors: Selectors{Item: "li", Title: "h3"}}
+		result, err := source.Fetch(context.Background(), CacheValidators{ETag: `"v1"`}, DefaultLimits)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if !result.NotModified {
+			t.Errorf("expected the page to be reported not modified")
+		}
+	})
+
+	t.Run("max items", func(t *testing.T) {
+		source := ScrapeSource{URL: server.URL, Selectors: Selectors{Item: "li", Title: "h3"}}
+		_, err := source.Fetch(context.Background(), CacheValidators{}, Limits{MaxItems: 1})
+		if !errors.Is(err, ErrMaxItems) {
+			t.Errorf("expected ErrMaxItems, got %v", err)
+		}
+	})
+}
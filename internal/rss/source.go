@@ -0,0 +1,225 @@
+package rss
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Source is where a feed is read from
+type Source interface {
+	// Fetch reads and parses the feed. Sources that can tell whether the feed
+	// changed use the validators and report NotModified
+	Fetch(ctx context.Context, validators CacheValidators, limits Limits) (*FetchResult, error)
+}
+
+// NewSource calls NewSource on DefaultClient
+func NewSource(feedURL string) (Source, error) {
+	return DefaultClient.NewSource(feedURL)
+}
+
+// NewSource returns the source for a feed url, picked by its scheme: http and
+// https urls are fetched over the network with the client and file urls are
+// read from the local disk
+func (c *Client) NewSource(feedURL string) (Source, error) {
+	return c.NewTypedSource("", feedURL, nil)
+}
+
+// httpAdapter fetches the feed at an http or https url
+func httpAdapter(c *Client, feedURL string, config json.RawMessage) (Source, error) {
+	return HTTPSource{URL: feedURL, Client: c}, nil
+}
+
+// fileAdapter reads the feed from the local file of a file url
+func fileAdapter(c *Client, feedURL string, config json.RawMessage) (Source, error) {
+	parsed, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed url %q: %w", feedURL, err)
+	}
+	path, err := filePath(parsed)
+	if err != nil {
+		return nil, err
+	}
+	return FileSource{Path: path}, nil
+}
+
+// filePath returns the local path of a file url, "file:///abs/feed.xml" and
+// the relative "file:feed.xml" are accepted
+func filePath(fileURL *url.URL) (string, error) {
+	if fileURL.Host != "" && fileURL.Host != "localhost" {
+		return "", fmt.Errorf("file url %q points to another host", fileURL.String())
+	}
+	path := fileURL.Path
+	if fileURL.Opaque != "" {
+		path = fileURL.Opaque
+	}
+	if path == "" {
+		return "", fmt.Errorf("file url %q has no path", fileURL.String())
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// HTTPSource fetches the feed with an HTTP GET, using conditional requests
+// and following redirects. A nil Client uses DefaultClient
+type HTTPSource struct {
+	URL    string
+	Client *Client
+}
+
+func (src HTTPSource) Fetch(ctx context.Context, validators CacheValidators, limits Limits) (*FetchResult, error) {
+	limits = limits.withDefaults()
+
+	header := http.Header{}
+	if validators.ETag != "" {
+		header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	client := src.Client
+	if client == nil {
+		client = DefaultClient
+	}
+	resp, err := client.get(ctx, src.URL, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &FetchResult{
+			Validators:  validators,
+			NotModified: true,
+			MovedTo:     permanentLocation(resp),
+		}, nil
+	}
+
+	body, err := limitBody(resp, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	feedData, err := parseFeed(body, resp.Request.URL, resp.Header.Get("Content-Type"), limits)
+	if err != nil {
+		return nil, err
+	}
+	// WebSub publishers can advertise the hub in Link headers, which take
+	// precedence over the links in the feed
+	if hub := headerLink(resp.Header, "hub"); hub != "" {
+		feedData.Channel.Hub = resolveURL(resp.Request.URL, hub)
+		feedData.Channel.Self = resolveURL(resp.Request.URL, headerLink(resp.Header, "self"))
+	}
+
+	return &FetchResult{
+		Feed: feedData,
+		Validators: CacheValidators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+		MovedTo: permanentLocation(resp),
+	}, nil
+}
+
+// headerLink returns the target of the first link with the rel in the
+// Link headers, formatted as `<https://hub.example.com/>; rel="hub"`
+func headerLink(header http.Header, rel string) string {
+	for _, value := range header.Values("Link") {
+		for link := range strings.SplitSeq(value, ",") {
+			target, params, found := strings.Cut(link, ";")
+			target = strings.TrimSpace(target)
+			if !found || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for param := range strings.SplitSeq(params, ";") {
+				name, value, _ := strings.Cut(param, "=")
+				if strings.TrimSpace(strings.ToLower(name)) != "rel" {
+					continue
+				}
+				if slices.Contains(strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)), rel) {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// FileSource reads the feed from a local file. The modification time of the
+// file is used as its Last-Modified validator
+type FileSource struct {
+	Path string
+}
+
+func (src FileSource) Fetch(ctx context.Context, validators CacheValidators, limits Limits) (*FetchResult, error) {
+	limits = limits.withDefaults()
+
+	file, err := os.Open(src.Path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open feed file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read feed file: %w", err)
+	}
+	if info.Size() > limits.MaxBodySize {
+		return nil, fmt.Errorf("%w: file of %d bytes is larger than %d bytes", ErrMaxBodySize, info.Size(), limits.MaxBodySize)
+	}
+
+	lastModified := info.ModTime().UTC().Format(http.TimeFormat)
+	if validators.LastModified == lastModified {
+		return &FetchResult{
+			Validators:  validators,
+			NotModified: true,
+		}, nil
+	}
+
+	absPath, err := filepath.Abs(src.Path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read feed file: %w", err)
+	}
+	fileURL := &url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}
+
+	feedData, err := parseFeed(newLimitedReader(file, limits.MaxBodySize), fileURL, "", limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FetchResult{
+		Feed:       feedData,
+		Validators: CacheValidators{LastModified: lastModified},
+	}, nil
+}
+
+// ReaderSource reads the feed once from a stream such as the standard input.
+// It can't tell whether the feed changed, so the validators are kept as they are
+type ReaderSource struct {
+	Reader io.Reader
+	// BaseURL resolves relative links when the feed has no site link, it can be nil
+	BaseURL *url.URL
+	// ContentType is the media type of the feed when it is known, it can be empty
+	ContentType string
+}
+
+func (src ReaderSource) Fetch(ctx context.Context, validators CacheValidators, limits Limits) (*FetchResult, error) {
+	limits = limits.withDefaults()
+
+	feedData, err := parseFeed(newLimitedReader(src.Reader, limits.MaxBodySize), src.BaseURL, src.ContentType, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FetchResult{
+		Feed:       feedData,
+		Validators: validators,
+	}, nil
+}
@@ -0,0 +1,203 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		feedURL     string
+		expected    Source
+		expectError bool
+	}{
+		{
+			name:     "https",
+			feedURL:  "https://example.com/feed.xml",
+			expected: HTTPSource{URL: "https://example.com/feed.xml", Client: DefaultClient},
+		},
+		{
+			name:     "absolute file",
+			feedURL:  "file:///var/feeds/feed.xml",
+			expected: FileSource{Path: filepath.FromSlash("/var/feeds/feed.xml")},
+		},
+		{
+			name:     "localhost file",
+			feedURL:  "file://localhost/var/feeds/feed.xml",
+			expected: FileSource{Path: filepath.FromSlash("/var/feeds/feed.xml")},
+		},
+		{
+			name:     "relative file",
+			feedURL:  "file:feeds/feed.xml",
+			expected: FileSource{Path: filepath.FromSlash("feeds/feed.xml")},
+		},
+		{
+			name:        "file on another host",
+			feedURL:     "file://server/feed.xml",
+			expectError: true,
+		},
+		{
+			name:        "unsupported scheme",
+			feedURL:     "ftp://example.com/feed.xml",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSource(tt.feedURL)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSource() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	if err := os.WriteFile(path, readFixture(t, "rss2.xml"), 0o644); err != nil {
+		t.Fatalf("couldn't write feed file: %v", err)
+	}
+	feedURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+
+	first, err := FetchFeedConditional(context.Background(), feedURL, CacheValidators{}, DefaultLimits)
+	if err != nil {
+		t.Fatalf("FetchFeedConditional() error = %v", err)
+	}
+	if first.NotModified || len(first.Feed.Channel.Item) != 2 {
+		t.Fatalf("expected the 2 items of the file, got %+v", first)
+	}
+	if first.Validators.LastModified == "" {
+		t.Errorf("expected the modification time as validator")
+	}
+
+	second, err := FetchFeedConditional(context.Background(), feedURL, first.Validators, DefaultLimits)
+	if err != nil {
+		t.Fatalf("FetchFeedConditional() error = %v", err)
+	}
+	if !second.NotModified {
+		t.Errorf("expected an unchanged file to be not modified")
+	}
+
+	_, err = FetchFeedConditional(context.Background(), feedURL, CacheValidators{}, Limits{MaxBodySize: 10})
+	if !errors.Is(err, ErrMaxBodySize) {
+		t.Errorf("expected ErrMaxBodySize for a large file, got %v", err)
+	}
+
+	feeds, err := DiscoverFeeds(context.Background(), feedURL, DefaultLimits)
+	if err != nil {
+		t.Fatalf("DiscoverFeeds() error = %v", err)
+	}
+	if len(feeds) != 1 || feeds[0].URL != feedURL || feeds[0].Title != "Gator Test Blog" {
+		t.Errorf("expected the file to be discovered as the feed, got %+v", feeds)
+	}
+}
+
+func TestReaderSource(t *testing.T) {
+	validators := CacheValidators{ETag: `"v1"`}
+	source := ReaderSource{Reader: bytes.NewReader(readFixture(t, "jsonfeed.json"))}
+
+	got, err := source.Fetch(context.Background(), validators, DefaultLimits)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(got.Feed.Channel.Item) != 2 {
+		t.Errorf("expected 2 items, got %d", len(got.Feed.Channel.Item))
+	}
+	if got.Validators != validators {
+		t.Errorf("expected the validators to be kept, got %+v", got.Validators)
+	}
+}
+
+func TestHTTPSource_WebSubHub(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		body         string
+		expectedHub  string
+		expectedSelf string
+	}{
+		{
+			name: "rss with atom links",
+			body: `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Blog</title>
+<atom:link rel="self" href="/feed.xml"/><atom:link rel="hub" href="https://hub.example.com/"/>
+</channel></rss>`,
+			expectedHub:  "https://hub.example.com/",
+			expectedSelf: "{server}/feed.xml",
+		},
+		{
+			name: "atom",
+			body: `<feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title>
+<link rel="hub" href="https://hub.example.com/"/><link rel="self" href="https://example.com/atom.xml"/>
+</feed>`,
+			expectedHub:  "https://hub.example.com/",
+			expectedSelf: "https://example.com/atom.xml",
+		},
+		{
+			name: "rdf",
+			body: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel><title>Blog</title><atom:link rel="hub" href="https://hub.example.com/"/></channel></rdf:RDF>`,
+			expectedHub: "https://hub.example.com/",
+		},
+		{
+			name:         "json feed",
+			body:         `{"version":"https://jsonfeed.org/version/1.1","title":"Blog","feed_url":"https://example.com/feed.json","hubs":[{"type":"rssCloud","url":"https://cloud.example.com/"},{"type":"WebSub","url":"https://hub.example.com/"}],"items":[]}`,
+			expectedHub:  "https://hub.example.com/",
+			expectedSelf: "https://example.com/feed.json",
+		},
+		{
+			name:         "link headers take precedence",
+			header:       `<https://push.example.com/>; rel="hub", <https://example.com/canonical.xml>; rel="self"`,
+			body:         `<feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title><link rel="hub" href="https://hub.example.com/"/></feed>`,
+			expectedHub:  "https://push.example.com/",
+			expectedSelf: "https://example.com/canonical.xml",
+		},
+		{
+			name: "no hub",
+			body: `<rss><channel><title>Blog</title></channel></rss>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("Link", tt.header)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			got, err := FetchFeedConditional(context.Background(), server.URL, CacheValidators{}, DefaultLimits)
+			if err != nil {
+				t.Fatalf("FetchFeedConditional() error = %v", err)
+			}
+			if got.Feed.Channel.Hub != tt.expectedHub {
+				t.Errorf("expected hub %q, got %q", tt.expectedHub, got.Feed.Channel.Hub)
+			}
+			expectedSelf := strings.ReplaceAll(tt.expectedSelf, "{server}", server.URL)
+			if got.Feed.Channel.Self != expectedSelf {
+				t.Errorf("expected self %q, got %q", expectedSelf, got.Feed.Channel.Self)
+			}
+		})
+	}
+}
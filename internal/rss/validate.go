@@ -0,0 +1,347 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ManoloEsS/gator_cli/internal/pubdate"
+	"golang.org/x/net/html/charset"
+)
+
+// severities of the problems found by Validate, errors are problems that make
+// gator lose the feed or some of its items, warnings are worked around
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// names of the feed formats reported by Validate
+const (
+	FormatRSS  = "RSS 2.0"
+	FormatAtom = "Atom 1.0"
+	FormatRDF  = "RSS 1.0 (RDF)"
+	FormatJSON = "JSON Feed"
+)
+
+// media types a feed of each format should be served with, the first one is
+// the preferred type
+var formatMediaTypes = map[string][]string{
+	FormatRSS:  {"application/rss+xml", "application/xml", "text/xml"},
+	FormatAtom: {"application/atom+xml", "application/xml", "text/xml"},
+	FormatRDF:  {"application/rdf+xml", "application/rss+xml", "application/xml", "text/xml"},
+	FormatJSON: {"application/feed+json", "application/json"},
+}
+
+// Problem is something wrong with a feed found by Validate. Item is the
+// position of the item it is about starting at 1, or 0 for the feed itself
+type Problem struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Item     int    `json:"item,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Report is the result of validating a feed. Feed is nil when the feed
+// couldn't be fetched or parsed
+type Report struct {
+	URL         string    `json:"url"`
+	FinalURL    string    `json:"final_url,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Format      string    `json:"format,omitempty"`
+	Problems    []Problem `json:"problems"`
+	Feed        *RSSFeed  `json:"-"`
+}
+
+// HasErrors reports whether any of the problems is an error
+func (r *Report) HasErrors() bool {
+	return slices.ContainsFunc(r.Problems, func(p Problem) bool {
+		return p.Severity == SeverityError
+	})
+}
+
+func (r *Report) add(severity, code string, item int, format string, args ...any) {
+	r.Problems = append(r.Problems, Problem{
+		Severity: severity,
+		Code:     code,
+		Item:     item,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Validate calls Validate on DefaultClient
+func Validate(ctx context.Context, feedURL string, limits Limits) *Report {
+	return DefaultClient.Validate(ctx, feedURL, limits)
+}
+
+// Validate fetches the feed like the aggregator does and reports every
+// problem found on the way: HTTP errors and redirects, content types,
+// charsets, parse errors and items gator can't store as they are. The
+// source is picked by the scheme of the url like NewSource does
+func (c *Client) Validate(ctx context.Context, feedURL string, limits Limits) *Report {
+	return c.ValidateTyped(ctx, "", feedURL, nil, limits)
+}
+
+// ValidateTyped calls ValidateTyped on DefaultClient
+func ValidateTyped(ctx context.Context, sourceType, feedURL string, config json.RawMessage, limits Limits) *Report {
+	return DefaultClient.ValidateTyped(ctx, sourceType, feedURL, config, limits)
+}
+
+// ValidateTyped validates the feed read from the source NewTypedSource
+// returns. Feeds fetched over HTTP get every check, the feeds of other
+// sources are only checked once they are read
+func (c *Client) ValidateTyped(ctx context.Context, sourceType, feedURL string, config json.RawMessage, limits Limits) *Report {
+	limits = limits.withDefaults()
+	report := &Report{
+		URL:      feedURL,
+		Problems: []Problem{},
+	}
+
+	source, err := c.NewTypedSource(sourceType, feedURL, config)
+	if err != nil {
+		report.add(SeverityError, "unsupported-source", 0, "%v", err)
+		return report
+	}
+	if src, ok := source.(HTTPSource); ok {
+		c.validateHTTP(ctx, report, src.URL, limits)
+		return report
+	}
+
+	result, err := source.Fetch(ctx, CacheValidators{}, limits)
+	if err != nil {
+		code := "fetch-failed"
+		switch {
+		case errors.Is(err, ErrMaxBodySize):
+			code = "too-large"
+		case errors.Is(err, ErrMaxItems):
+			code = "too-many-items"
+		}
+		report.add(SeverityError, code, 0, "%v", err)
+		return report
+	}
+	report.Feed = result.Feed
+	checkFeed(report, result.Feed)
+
+	return report
+}
+
+// validateHTTP fetches the feed at feedURL and adds the problems of the
+// response and of the feed to the report
+func (c *Client) validateHTTP(ctx context.Context, report *Report, feedURL string, limits Limits) {
+	resp, err := c.get(ctx, feedURL, nil)
+	if err != nil {
+		report.add(SeverityError, "fetch-failed", 0, "%v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	report.FinalURL = resp.Request.URL.String()
+	report.Status = resp.Status
+	report.ContentType = resp.Header.Get("Content-Type")
+	if moved := permanentLocation(resp); moved != "" {
+		report.add(SeverityWarning, "moved-permanently", 0, "the feed permanently redirects to %s, gator updates the stored url", moved)
+	}
+
+	limitedBody, err := limitBody(resp, limits)
+	if err != nil {
+		report.add(SeverityError, "too-large", 0, "%v", err)
+		return
+	}
+	body, err := io.ReadAll(limitedBody)
+	if err != nil {
+		code := "fetch-failed"
+		if errors.Is(err, ErrMaxBodySize) {
+			code = "too-large"
+		}
+		report.add(SeverityError, code, 0, "%v", err)
+		return
+	}
+
+	report.Format = detectFormat(body, report.ContentType)
+	checkContentType(report)
+	checkCharset(report, body)
+
+	feed, err := parseFeed(bytes.NewReader(body), resp.Request.URL, report.ContentType, limits)
+	if err != nil {
+		code := "parse-failed"
+		if errors.Is(err, ErrMaxItems) {
+			code = "too-many-items"
+		}
+		report.add(SeverityError, code, 0, "%v", err)
+		return
+	}
+	report.Feed = feed
+	checkFeed(report, feed)
+}
+
+// detectFormat names the format of the feed the same way parseFeed picks
+// its decoder, it is empty when the format is unknown
+func detectFormat(body []byte, contentType string) string {
+	if isJSONFeed(contentType, body[:min(len(body), sniffLen)]) {
+		return FormatJSON
+	}
+	decoder, err := newXMLDecoder(bytes.NewReader(body), contentType)
+	if err != nil {
+		return ""
+	}
+	root, err := rootElement(decoder)
+	if err != nil {
+		return ""
+	}
+	switch root.Name.Local {
+	case "rss":
+		return FormatRSS
+	case "feed":
+		return FormatAtom
+	case "RDF":
+		return FormatRDF
+	}
+	return ""
+}
+
+func checkContentType(report *Report) {
+	if report.ContentType == "" {
+		report.add(SeverityWarning, "missing-content-type", 0, "the response has no Content-Type, gator guesses the format from the body")
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(report.ContentType)
+	if err != nil {
+		report.add(SeverityWarning, "bad-content-type", 0, "invalid Content-Type %q: %v", report.ContentType, err)
+		return
+	}
+	expected, ok := formatMediaTypes[report.Format]
+	if ok && !slices.Contains(expected, mediaType) {
+		report.add(SeverityWarning, "wrong-content-type", 0, "served as %s, a %s feed should be served as %s", mediaType, report.Format, expected[0])
+	}
+}
+
+// xmlDeclEncoding matches the encoding of the XML declaration
+var xmlDeclEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([^"']+)["']`)
+
+func checkCharset(report *Report, body []byte) {
+	headerLabel := contentTypeCharset(report.ContentType)
+
+	if report.Format == FormatJSON {
+		if headerLabel != "" && !isUTF8(headerLabel) {
+			report.add(SeverityWarning, "charset-mismatch", 0, "JSON Feeds are UTF-8 but the Content-Type says %s", headerLabel)
+		}
+		if !utf8.Valid(body) {
+			report.add(SeverityError, "invalid-utf8", 0, "the feed is not valid UTF-8")
+		}
+		return
+	}
+
+	declLabel := ""
+	if match := xmlDeclEncoding.FindSubmatch(body[:min(len(body), sniffLen)]); match != nil {
+		declLabel = string(match[1])
+	}
+
+	for _, label := range []string{headerLabel, declLabel} {
+		if label == "" {
+			continue
+		}
+		if _, name := charset.Lookup(label); name == "" {
+			report.add(SeverityError, "unknown-charset", 0, "unknown charset %q", label)
+			return
+		}
+	}
+
+	// same precedence as newXMLDecoder
+	effective := "utf-8"
+	switch {
+	case headerLabel != "" && !isUTF8(headerLabel):
+		effective = headerLabel
+	case declLabel != "":
+		effective = declLabel
+	}
+
+	if headerLabel != "" && declLabel != "" && !sameCharset(headerLabel, declLabel) {
+		report.add(SeverityWarning, "charset-mismatch", 0, "the Content-Type says %s but the XML declaration says %s, gator decodes the feed as %s", headerLabel, declLabel, effective)
+	}
+	if isUTF8(effective) && !utf8.Valid(body) {
+		report.add(SeverityError, "invalid-utf8", 0, "the feed is decoded as UTF-8 but it is not valid UTF-8, declare its charset in the XML declaration")
+	}
+}
+
+func sameCharset(a, b string) bool {
+	_, nameA := charset.Lookup(a)
+	_, nameB := charset.Lookup(b)
+	return nameA == nameB
+}
+
+func checkFeed(report *Report, feed *RSSFeed) {
+	if strings.TrimSpace(feed.Channel.Title) == "" {
+		report.add(SeverityWarning, "missing-title", 0, "the feed has no title")
+	}
+	if feed.Channel.Link == "" {
+		report.add(SeverityWarning, "missing-link", 0, "the feed has no site link, relative item links are resolved against the feed url")
+	}
+	if len(feed.Channel.Item) == 0 {
+		report.add(SeverityWarning, "no-items", 0, "the feed has no items")
+	}
+
+	seen := map[string]int{}
+	for i, item := range feed.Channel.Item {
+		n := i + 1
+
+		if strings.TrimSpace(item.Title) == "" && strings.TrimSpace(item.Description) == "" {
+			report.add(SeverityWarning, "empty-item", n, "the item has neither a title nor a description")
+		}
+
+		if item.Link == "" {
+			report.add(SeverityWarning, "missing-link", n, "the item has no link")
+		} else if link, err := url.Parse(item.Link); err != nil || !link.IsAbs() {
+			report.add(SeverityWarning, "bad-link", n, "%q is not an absolute url", item.Link)
+		}
+
+		if item.GUID == "" {
+			if item.Link != "" {
+				report.add(SeverityWarning, "missing-guid", n, "the item has no guid, gator identifies it by its link")
+			} else {
+				report.add(SeverityWarning, "missing-guid", n, "the item has neither a guid nor a link, gator identifies it by its content and stores edits as new posts")
+			}
+		}
+
+		identity := item.Identity()
+		if first, ok := seen[identity]; ok {
+			report.add(SeverityError, "duplicate-item", n, "same identity as item %d (%s), only the first one is stored", first, identity)
+		} else {
+			seen[identity] = n
+		}
+
+		checkDate(report, n, item.PubDate)
+
+		for _, enclosure := range item.Enclosures {
+			if enclosure.URL == "" {
+				report.add(SeverityWarning, "bad-enclosure", n, "enclosure without url is skipped")
+			} else if enclosure.Type == "" {
+				report.add(SeverityWarning, "bad-enclosure", n, "enclosure %s has no type", enclosure.URL)
+			}
+		}
+	}
+}
+
+func checkDate(report *Report, n int, date string) {
+	if strings.TrimSpace(date) == "" {
+		report.add(SeverityWarning, "missing-date", n, "the item has no date, gator dates it when it is fetched")
+		return
+	}
+	parsed, err := pubdate.Parse(date)
+	if err != nil {
+		report.add(SeverityWarning, "bad-date", n, "%q can't be parsed, gator dates the item when it is fetched", date)
+		return
+	}
+	if parsed.Guessed {
+		report.add(SeverityWarning, "guessed-date", n, "%q has no time zone or time of day, gator assumes UTC", date)
+	}
+}
@@ -0,0 +1,218 @@
+package rss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	const cleanFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Blog</title><link>https://example.com/</link>
+<item><title>Post</title><link>https://example.com/post</link><guid>post</guid><pubDate>Mon, 06 May 2024 10:00:00 +0000</pubDate></item>
+</channel></rss>`
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		status         int
+		expectedFormat string
+		expectedCodes  []string
+		expectErrors   bool
+		expectFeed     bool
+	}{
+		{
+			name:           "clean feed",
+			contentType:    "application/rss+xml; charset=utf-8",
+			body:           cleanFeed,
+			expectedFormat: FormatRSS,
+			expectFeed:     true,
+		},
+		{
+			name:           "wrong and missing content types",
+			contentType:    "text/html",
+			body:           cleanFeed,
+			expectedFormat: FormatRSS,
+			expectedCodes:  []string{"wrong-content-type"},
+			expectFeed:     true,
+		},
+		{
+			name:          "HTTP error",
+			status:        http.StatusNotFound,
+			body:          "not found",
+			expectedCodes: []string{"fetch-failed"},
+			expectErrors:  true,
+		},
+		{
+			name:           "bad XML",
+			contentType:    "application/rss+xml",
+			body:           `<rss><channel><title>Blog</title><item></channel></rss>`,
+			expectedFormat: FormatRSS,
+			expectedCodes:  []string{"parse-failed"},
+			expectErrors:   true,
+		},
+		{
+			name:           "permanent redirect",
+			path:           "/old",
+			contentType:    "application/rss+xml",
+			body:           cleanFeed,
+			expectedFormat: FormatRSS,
+			expectedCodes:  []string{"moved-permanently"},
+			expectFeed:     true,
+		},
+		{
+			name:           "charset mismatch",
+			contentType:    "text/xml; charset=iso-8859-2",
+			body:           `<?xml version="1.0" encoding="ISO-8859-1"?>` + cleanFeed[len(`<?xml version="1.0" encoding="UTF-8"?>`):],
+			expectedFormat: FormatRSS,
+			expectedCodes:  []string{"charset-mismatch"},
+			expectFeed:     true,
+		},
+		{
+			name:           "invalid UTF-8",
+			contentType:    "application/rss+xml",
+			body:           "<rss><channel><title>Caf\xe9</title></channel></rss>",
+			expectedFormat: FormatRSS,
+			expectedCodes:  []string{"invalid-utf8", "parse-failed"},
+			expectErrors:   true,
+		},
+		{
+			name:        "item problems",
+			contentType: "application/rss+xml",
+			body: `<rss><channel><title></title>
+<item><title>No guid</title><link>https://example.com/1</link><pubDate>yesterday</pubDate></item>
+<item><title>Duplicate</title><link>https://example.com/1</link><pubDate>2024-05-06</pubDate></item>
+<item><description>Only a description</description>
+<enclosure url="https://example.com/ep.mp3"/></item>
+</channel></rss>`,
+			expectedFormat: FormatRSS,
+			expectedCodes: []string{
+				"missing-title", "missing-link",
+				"missing-guid", "bad-date",
+				"missing-guid", "duplicate-item", "guessed-date",
+				"missing-link", "missing-guid", "missing-date", "bad-enclosure",
+			},
+			expectErrors: true,
+			expectFeed:   true,
+		},
+		{
+			name:           "JSON Feed",
+			contentType:    "application/json",
+			body:           `{"version": "https://jsonfeed.org/version/1.1", "title": "Notes", "home_page_url": "https://example.com/", "items": []}`,
+			expectedFormat: FormatJSON,
+			expectedCodes:  []string{"no-items"},
+			expectFeed:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/old" {
+					http.Redirect(w, r, "/feed", http.StatusMovedPermanently)
+					return
+				}
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			report := Validate(context.Background(), server.URL+tt.path, DefaultLimits)
+
+			codes := []string{}
+			for _, problem := range report.Problems {
+				codes = append(codes, problem.Code)
+			}
+			expectedCodes := tt.expectedCodes
+			if expectedCodes == nil {
+				expectedCodes = []string{}
+			}
+			if !reflect.DeepEqual(codes, expectedCodes) {
+				t.Errorf("expected problems %v, got %+v", expectedCodes, report.Problems)
+			}
+			if report.Format != tt.expectedFormat {
+				t.Errorf("expected format %q, got %q", tt.expectedFormat, report.Format)
+			}
+			if report.HasErrors() != tt.expectErrors {
+				t.Errorf("expected HasErrors() = %v, got %v", tt.expectErrors, report.HasErrors())
+			}
+			if (report.Feed != nil) != tt.expectFeed {
+				t.Errorf("expected feed = %v, got %+v", tt.expectFeed, report.Feed)
+			}
+		})
+	}
+}
+
+func TestValidateTyped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	err := os.WriteFile(path, []byte(`<rss><channel><title>Local</title>
+<item><title>Post</title><link>https://example.com/post</link></item>
+</channel></rss>`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileURL := "file://" + filepath.ToSlash(path)
+
+	tests := []struct {
+		name          string
+		sourceType    string
+		url           string
+		limits        Limits
+		expectedCodes []string
+		expectFeed    bool
+	}{
+		{
+			name:          "file feed",
+			url:           fileURL,
+			limits:        DefaultLimits,
+			expectedCodes: []string{"missing-link", "missing-guid", "missing-date"},
+			expectFeed:    true,
+		},
+		{
+			name:          "file larger than the limit",
+			url:           fileURL,
+			limits:        Limits{MaxBodySize: 16},
+			expectedCodes: []string{"too-large"},
+		},
+		{
+			name:          "unsupported scheme",
+			url:           "gopher://example.com/feed",
+			limits:        DefaultLimits,
+			expectedCodes: []string{"unsupported-source"},
+		},
+		{
+			name:          "unknown source type",
+			sourceType:    "gopher",
+			url:           fileURL,
+			limits:        DefaultLimits,
+			expectedCodes: []string{"unsupported-source"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ValidateTyped(context.Background(), tt.sourceType, tt.url, nil, tt.limits)
+			codes := []string{}
+			for _, problem := range report.Problems {
+				codes = append(codes, problem.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.expectedCodes) {
+				t.Errorf("expected problems %v, got %v", tt.expectedCodes, report.Problems)
+			}
+			if (report.Feed != nil) != tt.expectFeed {
+				t.Errorf("expected feed %v, got %v", tt.expectFeed, report.Feed != nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+// Package sanitize cleans the HTML of feed items with an allowlist, so the
+// stored content can be put in any HTML page without running anything
+package sanitize
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements are the elements kept and their allowed attributes, other
+// elements are removed but their text is kept
+var allowedElements = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    nil,
+	"cite":       nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        {"datetime"},
+	"dfn":        nil,
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        {"datetime"},
+	"kbd":        nil,
+	"li":         nil,
+	"mark":       nil,
+	"ol":         {"start", "reversed"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"samp":       nil,
+	"small":      nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan", "scope"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+	"var":        nil,
+}
+
+// droppedElements are removed along with everything inside them
+var droppedElements = map[string]bool{
+	"applet":   true,
+	"audio":    true,
+	"button":   true,
+	"form":     true,
+	"frameset": true,
+	"head":     true,
+	"iframe":   true,
+	"math":     true,
+	"noembed":  true,
+	"noframes": true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"select":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+	"video":    true,
+}
+
+// droppedVoidElements are removed too, they have no content or end tag so
+// they are skipped on their own
+var droppedVoidElements = map[string]bool{
+	"embed":  true,
+	"frame":  true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+}
+
+var voidElements = map[string]bool{
+	"br":  true,
+	"hr":  true,
+	"img": true,
+}
+
+// urlAttrs are the attributes holding urls, which must use one of the
+// allowedSchemes or be relative
+var urlAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// HTML returns the fragment with only the allowed elements and attributes.
+// Scripts, styles, frames, forms, event handlers, javascript: urls and
+// tracking pixels are removed and the open elements are closed. Plain text
+// is escaped like the text between tags
+func HTML(fragment string) string {
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	var out strings.Builder
+	var open []string
+	// element being dropped with its content and how deep it is nested in itself
+	dropping, depth := "", 0
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF or broken markup, what was read so far is kept
+			break
+		}
+		token := z.Token()
+
+		if dropping != "" {
+			switch {
+			case tt == html.StartTagToken && token.Data == dropping:
+				depth++
+			case tt == html.EndTagToken && token.Data == dropping:
+				depth--
+				if depth == 0 {
+					dropping = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			out.WriteString(html.EscapeString(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedVoidElements[token.Data] {
+				continue
+			}
+			if droppedElements[token.Data] {
+				if tt == html.StartTagToken {
+					dropping, depth = token.Data, 1
+				}
+				continue
+			}
+			allowed, ok := allowedElements[token.Data]
+			if !ok {
+				continue
+			}
+			token.Attr = cleanAttrs(token.Attr, allowed)
+			if token.Data == "img" && !keepImage(token.Attr) {
+				continue
+			}
+			if voidElements[token.Data] {
+				token.Type = html.StartTagToken
+				out.WriteString(token.String())
+				continue
+			}
+			if tt == html.SelfClosingTagToken {
+				token.Type = html.StartTagToken
+				out.WriteString(token.String())
+				out.WriteString("</" + token.Data + ">")
+				continue
+			}
+			out.WriteString(token.String())
+			open = append(open, token.Data)
+		case html.EndTagToken:
+			// closes the elements left open inside the one that ends, end tags
+			// of elements that aren't open are dropped
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return out.String()
+}
+
+// Text returns the text of a value shown without markup, like a title or an
+// author name. HTML elements are removed, with the content of the ones HTML
+// drops, and whitespace is collapsed. Other text between angle brackets, like
+// the <T> of "List<T>", is kept
+func Text(fragment string) string {
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	var out strings.Builder
+	dropping, depth := "", 0
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		raw := string(z.Raw())
+		token := z.Token()
+
+		if dropping != "" {
+			switch {
+			case tt == html.StartTagToken && token.Data == dropping:
+				depth++
+			case tt == html.EndTagToken && token.Data == dropping:
+				depth--
+				if depth == 0 {
+					dropping = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			out.WriteString(token.Data)
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			if tt == html.StartTagToken && droppedElements[token.Data] {
+				dropping, depth = token.Data, 1
+				continue
+			}
+			if atom.Lookup([]byte(token.Data)) == 0 {
+				out.WriteString(raw)
+			}
+		}
+	}
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+// cleanAttrs keeps the allowed attributes with safe values
+func cleanAttrs(attrs []html.Attribute, allowed []string) []html.Attribute {
+	var kept []html.Attribute
+	for _, attr := range attrs {
+		if attr.Namespace != "" || !slices.Contains(allowed, attr.Key) {
+			continue
+		}
+		if urlAttrs[attr.Key] && !safeURL(attr.Val) {
+			continue
+		}
+		kept = append(kept, attr)
+	}
+	return kept
+}
+
+// safeURL reports whether the url is relative or uses an allowed scheme,
+// javascript: and data: urls are rejected
+func safeURL(raw string) bool {
+	// browsers ignore whitespace and control characters inside the scheme
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+	parsed, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	return parsed.Scheme == "" || allowedSchemes[strings.ToLower(parsed.Scheme)]
+}
+
+// keepImage reports whether the image has a source and isn't a tracking
+// pixel, an image of at most 1x1 pixels
+func keepImage(attrs []html.Attribute) bool {
+	hasSrc := false
+	tiny := 0
+	for _, attr := range attrs {
+		switch attr.Key {
+		case "src":
+			hasSrc = attr.Val != ""
+		case "width", "height":
+			if v := strings.TrimSuffix(strings.TrimSpace(attr.Val), "px"); v == "0" || v == "1" {
+				tiny++
+			}
+		}
+	}
+	return hasSrc && tiny < 2
+}
@@ -0,0 +1,157 @@
+package sanitize
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			input:    "Just text & more",
+			expected: "Just text &amp; more",
+		},
+		{
+			name:     "plain text with entities",
+			input:    "Tom &amp; Jerry &copy; 2024",
+			expected: "Tom &amp; Jerry © 2024",
+		},
+		{
+			name:     "text with entities and a tag",
+			input:    "<b>Tom</b> &amp; Jerry &copy; 2024",
+			expected: "<b>Tom</b> &amp; Jerry © 2024",
+		},
+		{
+			name:     "safe structure",
+			input:    `<p>Read <a href="https://example.com/post" title="Post">this</a></p><ul><li>one</li><li>two</li></ul><pre><code>go test ./...</code></pre>`,
+			expected: `<p>Read <a href="https://example.com/post" title="Post">this</a></p><ul><li>one</li><li>two</li></ul><pre><code>go test ./...</code></pre>`,
+		},
+		{
+			name:     "scripts and styles with their content",
+			input:    `<p>Hi</p><script>alert("x")</script><style>p { color: red }</style><noscript><img src="https://t.example.com/p.gif"></noscript>`,
+			expected: `<p>Hi</p>`,
+		},
+		{
+			name:     "iframes, objects and forms",
+			input:    `<iframe src="https://ads.example.com/"></iframe><object data="x.swf"><embed src="x.swf"></object><form action="/login"><input name="password"><button>Go</button></form>after`,
+			expected: `after`,
+		},
+		{
+			name:     "embed without end tag",
+			input:    `<p>Watch <embed src="a.swf"> then read the rest...</p><p>More</p>`,
+			expected: `<p>Watch  then read the rest...</p><p>More</p>`,
+		},
+		{
+			name:     "frame without end tag",
+			input:    `<p>a<frame src=x>b</p>`,
+			expected: `<p>ab</p>`,
+		},
+		{
+			name:     "media sources and tracks",
+			input:    `<p>Listen<source src="a.mp3"><track src="a.vtt"><param name="autoplay" value="true"> here</p>`,
+			expected: `<p>Listen here</p>`,
+		},
+		{
+			name:     "nested dropped elements",
+			input:    `<svg><svg><text>inner</text></svg><text>outer</text></svg>kept`,
+			expected: `kept`,
+		},
+		{
+			name:     "event handlers and styles",
+			input:    `<p onclick="steal()" style="display:none" class="lead">Text</p>`,
+			expected: `<p>Text</p>`,
+		},
+		{
+			name:     "javascript urls",
+			input:    `<a href="javascript:alert(1)">one</a><a href=" JaVa&#x09;Script:alert(1)">two</a><a href="data:text/html,x">three</a>`,
+			expected: `<a>one</a><a>two</a><a>three</a>`,
+		},
+		{
+			name:     "relative and mailto links",
+			input:    `<a href="/about">About</a> <a href="mailto:me@example.com">Mail</a>`,
+			expected: `<a href="/about">About</a> <a href="mailto:me@example.com">Mail</a>`,
+		},
+		{
+			name:     "images and tracking pixels",
+			input:    `<img src="https://example.com/photo.jpg" alt="Photo" width="640" onerror="x()"><img src="https://t.example.com/open.gif" width="1" height="1"><img src="javascript:x()">`,
+			expected: `<img src="https://example.com/photo.jpg" alt="Photo" width="640">`,
+		},
+		{
+			name:     "unknown elements keep their text",
+			input:    `<article><section><font color="red">Hello</font></section></article>`,
+			expected: `Hello`,
+		},
+		{
+			name:     "unclosed and stray tags",
+			input:    `<p><b>bold<i>both</p></b></div>text<em>open`,
+			expected: `<p><b>bold<i>both</i></b></p>text<em>open</em>`,
+		},
+		{
+			name:     "comments",
+			input:    `<!--[if IE]><script>x()</script><![endif]--><p>ok</p>`,
+			expected: `<p>ok</p>`,
+		},
+		{
+			name:     "escaped text stays escaped",
+			input:    `<p>&lt;script&gt;alert(1)&lt;/script&gt; &amp; more</p>`,
+			expected: `<p>&lt;script&gt;alert(1)&lt;/script&gt; &amp; more</p>`,
+		},
+		{
+			name:     "self closing elements",
+			input:    `line<br/>next<hr/><span/>`,
+			expected: `line<br>next<hr><span></span>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.input); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			input:    "Tom & Jerry",
+			expected: "Tom & Jerry",
+		},
+		{
+			name:     "markup is removed",
+			input:    `<b>Breaking:</b> <a href="https://example.com">gators</a> &amp; crocs`,
+			expected: "Breaking: gators & crocs",
+		},
+		{
+			name:     "dropped elements with their content",
+			input:    `Ali Gator<script>steal()</script><img src="x" onerror="steal()">`,
+			expected: "Ali Gator",
+		},
+		{
+			name:     "text that isn't markup",
+			input:    "Generics: List<T> and 1 < 2",
+			expected: "Generics: List<T> and 1 < 2",
+		},
+		{
+			name:     "whitespace is collapsed",
+			input:    "  Multi\n  line\ttitle ",
+			expected: "Multi line title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Text(tt.input); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package selector
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) skipSpaces() bool {
+	start := p.pos
+	for !p.done() && isSpace(p.peek()) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// complexSelector parses compound selectors joined by combinators, up to
+// the next comma or the end
+func (p *parser) complexSelector() ([]step, error) {
+	p.skipSpaces()
+	var steps []step
+	var combinator byte
+	for {
+		c, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step{combinator: combinator, compound: c})
+
+		spaced := p.skipSpaces()
+		switch {
+		case p.done() || p.peek() == ',':
+			return steps, nil
+		case p.peek() == '>':
+			p.pos++
+			p.skipSpaces()
+			combinator = '>'
+		case p.peek() == '+' || p.peek() == '~':
+			return nil, fmt.Errorf("sibling combinator %q is not supported", p.peek())
+		case spaced:
+			combinator = ' '
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+		}
+	}
+}
+
+func (p *parser) compound() (compound, error) {
+	var c compound
+	start := p.pos
+
+	if p.peek() == '*' {
+		p.pos++
+	} else if isNameChar(p.peek()) {
+		c.tag = strings.ToLower(p.name())
+	}
+
+	for !p.done() {
+		switch p.peek() {
+		case '#':
+			p.pos++
+			id := p.name()
+			if id == "" {
+				return c, errors.New("# without an id")
+			}
+			c.id = id
+		case '.':
+			p.pos++
+			class := p.name()
+			if class == "" {
+				return c, errors.New(". without a class")
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			p.pos++
+			matcher, err := p.attribute()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, matcher)
+		case ':':
+			return c, fmt.Errorf("pseudo-class at %d is not supported", p.pos)
+		default:
+			if p.pos == start {
+				return c, fmt.Errorf("expected a selector at %d", p.pos)
+			}
+			return c, nil
+		}
+	}
+	if p.pos == start {
+		return c, errors.New("empty selector")
+	}
+	return c, nil
+}
+
+// attribute parses the inside of [...], the opening bracket is already read
+func (p *parser) attribute() (attrMatcher, error) {
+	p.skipSpaces()
+	var m attrMatcher
+	m.key = strings.ToLower(p.name())
+	if m.key == "" {
+		return m, fmt.Errorf("expected an attribute name at %d", p.pos)
+	}
+	p.skipSpaces()
+
+	if p.peek() == ']' {
+		p.pos++
+		return m, nil
+	}
+	for _, op := range []string{"=", "~=", "^=", "$=", "*="} {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			m.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if m.op == "" {
+		return m, fmt.Errorf("unexpected %q in attribute selector at %d", p.peek(), p.pos)
+	}
+
+	p.skipSpaces()
+	if quote := p.peek(); quote == '"' || quote == '\'' {
+		end := strings.IndexByte(p.input[p.pos+1:], quote)
+		if end < 0 {
+			return m, errors.New("unterminated string in attribute selector")
+		}
+		m.value = p.input[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+	} else {
+		m.value = p.name()
+		if m.value == "" {
+			return m, fmt.Errorf("expected a value in attribute selector at %d", p.pos)
+		}
+	}
+
+	p.skipSpaces()
+	if p.peek() != ']' {
+		return m, errors.New("attribute selector without ]")
+	}
+	p.pos++
+	return m, nil
+}
+
+// name reads an identifier, letters, digits, '-' and '_'
+func (p *parser) name() string {
+	start := p.pos
+	for !p.done() && isNameChar(p.peek()) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c >= 0x80
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
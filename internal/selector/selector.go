@@ -0,0 +1,189 @@
+// Package selector matches HTML elements against CSS selectors. It supports
+// the subset needed to pick items out of a page: type, universal, #id, .class
+// and attribute selectors ([a], [a=v], [a~=v], [a^=v], [a$=v], [a*=v]),
+// the descendant and child combinators and comma separated lists
+package selector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selector is a parsed list of selectors, an element matches when it
+// matches any of them
+type Selector struct {
+	source string
+	list   [][]step
+}
+
+// step is a compound selector and the combinator that relates it to the
+// previous step
+type step struct {
+	// ' ' for descendant, '>' for child, 0 for the first step
+	combinator byte
+	compound   compound
+}
+
+type compound struct {
+	// element name or "" for any element
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrMatcher
+}
+
+type attrMatcher struct {
+	key string
+	// "" when the attribute only has to be present
+	op    string
+	value string
+}
+
+// Parse parses a selector list such as "div.post > h2 a, article"
+func Parse(s string) (Selector, error) {
+	p := &parser{input: s}
+	sel := Selector{source: strings.TrimSpace(s)}
+	for {
+		steps, err := p.complexSelector()
+		if err != nil {
+			return Selector{}, fmt.Errorf("invalid selector %q: %w", s, err)
+		}
+		sel.list = append(sel.list, steps)
+
+		p.skipSpaces()
+		if p.done() {
+			return sel, nil
+		}
+		if p.peek() != ',' {
+			return Selector{}, fmt.Errorf("invalid selector %q: unexpected %q at %d", s, p.peek(), p.pos)
+		}
+		p.pos++
+	}
+}
+
+// String returns the selector as it was written
+func (sel Selector) String() string {
+	return sel.source
+}
+
+// Match reports whether the element matches the selector
+func (sel Selector) Match(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+	for _, steps := range sel.list {
+		if matchSteps(n, steps) {
+			return true
+		}
+	}
+	return false
+}
+
+// All returns the elements inside root that match, in document order. Root
+// itself isn't matched
+func (sel Selector) All(root *html.Node) []*html.Node {
+	var matches []*html.Node
+	for n := range root.Descendants() {
+		if sel.Match(n) {
+			matches = append(matches, n)
+		}
+	}
+	return matches
+}
+
+// First returns the first element inside root that matches, or nil
+func (sel Selector) First(root *html.Node) *html.Node {
+	for n := range root.Descendants() {
+		if sel.Match(n) {
+			return n
+		}
+	}
+	return nil
+}
+
+// matchSteps matches the last step against the element and the others
+// against its ancestors, from right to left
+func matchSteps(n *html.Node, steps []step) bool {
+	last := steps[len(steps)-1]
+	if !last.compound.match(n) {
+		return false
+	}
+	if len(steps) == 1 {
+		return true
+	}
+
+	rest := steps[:len(steps)-1]
+	switch last.combinator {
+	case '>':
+		return isElement(n.Parent) && matchSteps(n.Parent, rest)
+	default:
+		for ancestor := n.Parent; isElement(ancestor); ancestor = ancestor.Parent {
+			if matchSteps(ancestor, rest) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isElement(n *html.Node) bool {
+	return n != nil && n.Type == html.ElementNode
+}
+
+func (c compound) match(n *html.Node) bool {
+	if c.tag != "" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" && attr(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(attr(n, "class"))
+		for _, class := range c.classes {
+			if !slices.Contains(classes, class) {
+				return false
+			}
+		}
+	}
+	for _, matcher := range c.attrs {
+		if !matcher.match(n) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m attrMatcher) match(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if a.Namespace != "" || a.Key != m.key {
+			continue
+		}
+		switch m.op {
+		case "":
+			return true
+		case "=":
+			return a.Val == m.value
+		case "~=":
+			return slices.Contains(strings.Fields(a.Val), m.value)
+		case "^=":
+			return m.value != "" && strings.HasPrefix(a.Val, m.value)
+		case "$=":
+			return m.value != "" && strings.HasSuffix(a.Val, m.value)
+		case "*=":
+			return m.value != "" && strings.Contains(a.Val, m.value)
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package selector
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const testPage = `<html><body>
+<div id="news" class="list">
+  <article class="post featured" data-kind="story"><h2><a href="/one">One</a></h2><time datetime="2025-01-02">Jan 2</time></article>
+  <article class="post"><h2><a href="https://example.com/two" rel="external nofollow">Two</a></h2></article>
+  <div class="post ad"><h2>Sponsored</h2></div>
+</div>
+<section><h2>Other</h2><p><a href="/three">Three</a></p></section>
+</body></html>`
+
+func TestSelector(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(testPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{selector: "h2", expected: []string{"One", "Two", "Sponsored", "Other"}},
+		{selector: "article h2", expected: []string{"One", "Two"}},
+		{selector: "ARTICLE.post", expected: []string{"OneJan 2", "Two"}},
+		{selector: ".post", expected: []string{"OneJan 2", "Two", "Sponsored"}},
+		{selector: ".post.ad", expected: []string{"Sponsored"}},
+		{selector: "#news > .post > h2", expected: []string{"One", "Two", "Sponsored"}},
+		{selector: "#news > h2", expected: nil},
+		{selector: "body a", expected: []string{"One", "Two", "Three"}},
+		{selector: "section>p   a", expected: []string{"Three"}},
+		{selector: "a[href^='/']", expected: []string{"One", "Three"}},
+		{selector: `a[href$=two]`, expected: []string{"Two"}},
+		{selector: "a[href*=example]", expected: []string{"Two"}},
+		{selector: "a[rel~=nofollow]", expected: []string{"Two"}},
+		{selector: "[data-kind=story] time[datetime]", expected: []string{"Jan 2"}},
+		{selector: "[data-kind=\"other\"]", expected: nil},
+		{selector: "article a, section a", expected: []string{"One", "Two", "Three"}},
+		{selector: "div *", expected: []string{"OneJan 2", "One", "One", "Jan 2", "Two", "Two", "Two", "Sponsored", "Sponsored"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := Parse(tt.selector)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var got []string
+			for _, n := range sel.All(doc) {
+				got = append(got, text(n))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"   ",
+		"a,",
+		"> a",
+		"a >",
+		"a + b",
+		"a ~ b",
+		"a:first-child",
+		"#",
+		"div.",
+		"[href",
+		"[=x]",
+		"[href=]",
+		"[href|=en]",
+		"[href='x]",
+		"a !b",
+	} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := Parse(input); err == nil {
+				t.Errorf("expected error but got none")
+			}
+		})
+	}
+}
+
+func text(n *html.Node) string {
+	var b strings.Builder
+	for child := range n.Descendants() {
+		if child.Type == html.TextNode {
+			b.WriteString(child.Data)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,168 @@
+// Package websub implements the subscriber side of WebSub
+// (https://www.w3.org/TR/websub/): subscription requests sent to hubs,
+// verification of intent and signature checks of pushed content
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// modes of the requests sent to and received from hubs
+const (
+	ModeSubscribe   = "subscribe"
+	ModeUnsubscribe = "unsubscribe"
+	ModeDenied      = "denied"
+)
+
+// DefaultLease is the lease asked for when subscribing, hubs can grant a
+// different one in the verification request
+const DefaultLease = 10 * 24 * time.Hour
+
+// Request is a subscription request sent to a hub. Callback is the url the
+// hub verifies the request with and pushes the content to
+type Request struct {
+	Hub      string
+	Topic    string
+	Callback string
+	// Secret signs the pushed content, it can be empty
+	Secret string
+	Lease  time.Duration
+}
+
+// Subscribe asks the hub to push the updates of the topic to the callback.
+// The hub confirms the subscription later with a verification request
+func Subscribe(ctx context.Context, client *http.Client, req Request) error {
+	return send(ctx, client, ModeSubscribe, req)
+}
+
+// Unsubscribe asks the hub to stop pushing the updates of the topic
+func Unsubscribe(ctx context.Context, client *http.Client, req Request) error {
+	return send(ctx, client, ModeUnsubscribe, req)
+}
+
+func send(ctx context.Context, client *http.Client, mode string, req Request) error {
+	form := url.Values{}
+	form.Set("hub.mode", mode)
+	form.Set("hub.topic", req.Topic)
+	form.Set("hub.callback", req.Callback)
+	if mode == ModeSubscribe {
+		if req.Lease > 0 {
+			form.Set("hub.lease_seconds", strconv.Itoa(int(req.Lease.Seconds())))
+		}
+		if req.Secret != "" {
+			form.Set("hub.secret", req.Secret)
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", req.Hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("couldn't create request for hub: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("couldn't get a response from the hub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("hub refused to %s: %s %s", mode, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Intent is a verification request of a hub, sent to the callback to confirm
+// a subscription or unsubscription, or to tell it was denied
+type Intent struct {
+	Mode      string
+	Topic     string
+	Challenge string
+	Lease     time.Duration
+	// Reason is why the hub denied the subscription
+	Reason string
+}
+
+// ParseIntent reads the verification request from the query of the callback url
+func ParseIntent(query url.Values) (Intent, error) {
+	intent := Intent{
+		Mode:      query.Get("hub.mode"),
+		Topic:     query.Get("hub.topic"),
+		Challenge: query.Get("hub.challenge"),
+		Reason:    query.Get("hub.reason"),
+	}
+	if intent.Topic == "" {
+		return Intent{}, errors.New("verification request without hub.topic")
+	}
+
+	switch intent.Mode {
+	case ModeDenied:
+		return intent, nil
+	case ModeSubscribe, ModeUnsubscribe:
+	default:
+		return Intent{}, fmt.Errorf("unknown hub.mode %q", intent.Mode)
+	}
+
+	if intent.Challenge == "" {
+		return Intent{}, errors.New("verification request without hub.challenge")
+	}
+	if intent.Mode == ModeSubscribe {
+		seconds, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || seconds <= 0 {
+			return Intent{}, fmt.Errorf("invalid hub.lease_seconds %q", query.Get("hub.lease_seconds"))
+		}
+		intent.Lease = time.Duration(seconds) * time.Second
+	}
+	return intent, nil
+}
+
+// NewSecret returns a random secret for the hub to sign the content with
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("couldn't generate secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// signatureHashes are the algorithms hubs can sign content with
+var signatureHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// ValidSignature reports whether the X-Hub-Signature header, formatted as
+// "sha256=<hex digest>", is the HMAC of the body with the secret
+func ValidSignature(secret string, body []byte, signature string) bool {
+	method, digest, found := strings.Cut(signature, "=")
+	newHash, ok := signatureHashes[strings.ToLower(method)]
+	if !found || !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,212 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		req         Request
+		expected    url.Values
+		expectError bool
+	}{
+		{
+			name:   "accepted",
+			status: http.StatusAccepted,
+			req: Request{
+				Topic:    "https://example.com/feed.xml",
+				Callback: "https://gator.example.com/websub/1",
+				Secret:   "s3cret",
+				Lease:    time.Hour,
+			},
+			expected: url.Values{
+				"hub.mode":          {"subscribe"},
+				"hub.topic":         {"https://example.com/feed.xml"},
+				"hub.callback":      {"https://gator.example.com/websub/1"},
+				"hub.secret":        {"s3cret"},
+				"hub.lease_seconds": {"3600"},
+			},
+		},
+		{
+			name:   "without secret or lease",
+			status: http.StatusAccepted,
+			req: Request{
+				Topic:    "https://example.com/feed.xml",
+				Callback: "https://gator.example.com/websub/1",
+			},
+			expected: url.Values{
+				"hub.mode":     {"subscribe"},
+				"hub.topic":    {"https://example.com/feed.xml"},
+				"hub.callback": {"https://gator.example.com/websub/1"},
+			},
+		},
+		{
+			name:        "refused",
+			status:      http.StatusBadRequest,
+			req:         Request{Topic: "https://example.com/feed.xml", Callback: "https://gator.example.com/websub/1"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got url.Values
+			hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				got = r.PostForm
+				w.WriteHeader(tt.status)
+			}))
+			defer hub.Close()
+
+			tt.req.Hub = hub.URL
+			err := Subscribe(context.Background(), hub.Client(), tt.req)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Errorf("expected form %v, got %v", tt.expected, got)
+			}
+			for key := range tt.expected {
+				if got.Get(key) != tt.expected.Get(key) {
+					t.Errorf("expected %s = %q, got %q", key, tt.expected.Get(key), got.Get(key))
+				}
+			}
+		})
+	}
+}
+
+func TestParseIntent(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		expected    Intent
+		expectError bool
+	}{
+		{
+			name:     "subscribe",
+			query:    "hub.mode=subscribe&hub.topic=https://example.com/feed.xml&hub.challenge=abc&hub.lease_seconds=86400",
+			expected: Intent{Mode: ModeSubscribe, Topic: "https://example.com/feed.xml", Challenge: "abc", Lease: 24 * time.Hour},
+		},
+		{
+			name:     "unsubscribe",
+			query:    "hub.mode=unsubscribe&hub.topic=https://example.com/feed.xml&hub.challenge=abc",
+			expected: Intent{Mode: ModeUnsubscribe, Topic: "https://example.com/feed.xml", Challenge: "abc"},
+		},
+		{
+			name:     "denied",
+			query:    "hub.mode=denied&hub.topic=https://example.com/feed.xml&hub.reason=spam",
+			expected: Intent{Mode: ModeDenied, Topic: "https://example.com/feed.xml", Reason: "spam"},
+		},
+		{
+			name:        "missing challenge",
+			query:       "hub.mode=subscribe&hub.topic=https://example.com/feed.xml&hub.lease_seconds=86400",
+			expectError: true,
+		},
+		{
+			name:        "missing lease",
+			query:       "hub.mode=subscribe&hub.topic=https://example.com/feed.xml&hub.challenge=abc",
+			expectError: true,
+		},
+		{
+			name:        "missing topic",
+			query:       "hub.mode=subscribe&hub.challenge=abc&hub.lease_seconds=86400",
+			expectError: true,
+		},
+		{
+			name:        "unknown mode",
+			query:       "hub.mode=publish&hub.topic=https://example.com/feed.xml&hub.challenge=abc",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseIntent(query)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseIntent() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidSignature(t *testing.T) {
+	body := []byte(`<feed xmlns="http://www.w3.org/2005/Atom"></feed>`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	digest := hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		expected  bool
+	}{
+		{
+			name:      "valid",
+			secret:    "s3cret",
+			signature: "sha256=" + digest,
+			expected:  true,
+		},
+		{
+			name:      "wrong secret",
+			secret:    "other",
+			signature: "sha256=" + digest,
+		},
+		{
+			name:      "wrong algorithm",
+			secret:    "s3cret",
+			signature: "sha1=" + digest,
+		},
+		{
+			name:      "unknown algorithm",
+			secret:    "s3cret",
+			signature: "md5=" + digest,
+		},
+		{
+			name:   "missing",
+			secret: "s3cret",
+		},
+		{
+			name:      "not hex",
+			secret:    "s3cret",
+			signature: "sha256=xyz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidSignature(tt.secret, body, tt.signature); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
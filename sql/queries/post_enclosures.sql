@@ -0,0 +1,34 @@
+-- name: CreatePostEnclosure :one
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, length, mime_type, duration, episode, season, episode_type)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
+    )
+RETURNING *;
+--
+
+-- name: GetEnclosuresForPost :many
+SELECT *
+FROM post_enclosures
+WHERE post_id = $1
+ORDER BY created_at;
+--
+
+-- name: GetEnclosuresForUser :many
+SELECT post_enclosures.*, posts.title AS post_title, posts.published_at, rssfeeds.name AS feed_name
+FROM post_enclosures
+INNER JOIN posts ON post_enclosures.post_id = posts.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN rssfeeds ON posts.feed_id = rssfeeds.id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.published_at DESC LIMIT $2;
+--
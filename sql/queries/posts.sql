@@ -1,5 +1,5 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, author, content, published_at_guessed)
 VALUES (
     $1,
     $2,
@@ -8,13 +8,40 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12
     )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id;
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, legacy_guid, author, content, published_at_guessed, article;
+--
+
+-- name: AdoptLegacyPost :execrows
+-- posts stored before guids were tracked are keyed by their link, the first
+-- item fetched again with that link gives them its guid
+UPDATE posts
+SET guid = $3,
+legacy_guid = false,
+updated_at = NOW()
+WHERE feed_id = $1 AND url = $2 AND legacy_guid;
+--
+
+-- name: DeleteLegacyDuplicate :execrows
+-- a post just created for an item whose link is the key of a legacy post is
+-- removed, so the legacy post can take its guid instead
+DELETE FROM posts
+WHERE posts.id = $1
+AND EXISTS (
+    SELECT 1 FROM posts legacy
+    WHERE legacy.feed_id = posts.feed_id
+    AND legacy.url = posts.url
+    AND legacy.legacy_guid
+);
 --
 
 -- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, rssfeeds.name AS feed_name
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.content, posts.published_at_guessed, rssfeeds.name AS feed_name
 FROM posts
 INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 INNER JOIN rssfeeds ON posts.feed_id = rssfeeds.id 
@@ -24,3 +51,32 @@ ORDER BY posts.published_at DESC LIMIT $2;
 
 
 
+
+-- name: GetPostsForUserByTag :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.content, posts.published_at_guessed, rssfeeds.name AS feed_name
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN rssfeeds ON posts.feed_id = rssfeeds.id
+INNER JOIN post_tags ON post_tags.post_id = posts.id
+INNER JOIN tags ON post_tags.tag_id = tags.id
+WHERE feed_follows.user_id = $1
+AND tags.name = $2
+ORDER BY posts.published_at DESC LIMIT $3;
+--
+
+-- name: GetPostForUser :one
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.content, posts.published_at_guessed, posts.article, rssfeeds.name AS feed_name
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN rssfeeds ON posts.feed_id = rssfeeds.id
+WHERE feed_follows.user_id = $1
+AND (posts.id::text = sqlc.arg(post)::text OR posts.url = sqlc.arg(post)::text)
+ORDER BY posts.published_at DESC LIMIT 1;
+--
+
+-- name: SetPostArticle :exec
+UPDATE posts
+SET article = $2,
+updated_at = NOW()
+WHERE id = $1;
+--
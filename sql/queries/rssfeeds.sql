@@ -1,14 +1,17 @@
 -- name: CreateRSSFeed :one
-INSERT INTO rssfeeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO rssfeeds (id, created_at, updated_at, name, url, user_id, fetch_full_article, source_type, source_config)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, name, url, user_id;
+RETURNING id, created_at, updated_at, name, url, user_id, fetch_full_article, source_type, source_config;
 
 -- name: GetFeeds :many
 SELECT users.name AS user_name, 
@@ -21,9 +24,17 @@ ORDER BY users.name;
 
 
 -- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_full_article, source_type, source_config
 FROM rssfeeds
-WHERE rssfeeds.Url = $1;
+WHERE rssfeeds.Url = $1
+OR rssfeeds.id IN (SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = $1)
+ORDER BY rssfeeds.url = $1 DESC
+LIMIT 1;
+
+-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_full_article, source_type, source_config
+FROM rssfeeds
+WHERE rssfeeds.id = $1;
 
 -- name: MarkFeedFetched :exec
 UPDATE rssfeeds 
@@ -31,7 +42,82 @@ SET last_fetched_at = NOW(),
 updated_at = NOW()
 WHERE id = $1;
 
--- name: GetNextFeedToFetch :one
-SELECT *
+-- name: GetFeedsByName :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_full_article, source_type, source_config
 FROM rssfeeds
-ORDER BY last_fetched_at ASC NULLS FIRST LIMIT 1;
+WHERE rssfeeds.name = $1
+ORDER BY rssfeeds.created_at;
+
+-- name: GetFollowedFeedsByName :many
+SELECT rssfeeds.*
+FROM rssfeeds
+INNER JOIN feed_follows ON feed_follows.feed_id = rssfeeds.id
+WHERE feed_follows.user_id = $1
+AND rssfeeds.name = $2
+ORDER BY rssfeeds.created_at;
+
+-- name: ClaimNextFeedToFetch :one
+-- the due feed is locked so concurrent workers skip it, and its next fetch
+-- is pushed to claimed_until so it isn't claimed again while it is fetched.
+-- Feeds pushed by a WebSub hub are only polled once their lease is over
+WITH due AS (
+    SELECT *
+    FROM rssfeeds
+    WHERE (next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now)::timestamp)
+    -- feeds with an active WebSub subscription are pushed by their hub
+    AND NOT EXISTS (
+        SELECT 1 FROM websub_subscriptions
+        WHERE websub_subscriptions.feed_id = rssfeeds.id
+        AND websub_subscriptions.lease_expires_at > sqlc.arg(now)::timestamp
+    )
+    ORDER BY last_fetched_at ASC NULLS FIRST LIMIT 1
+    FOR UPDATE SKIP LOCKED
+), claimed AS (
+    UPDATE rssfeeds
+    SET next_fetch_at = sqlc.arg(claimed_until)::timestamp
+    FROM due
+    WHERE rssfeeds.id = due.id
+)
+SELECT * FROM due;
+
+-- name: RenewFeedClaim :execrows
+-- the claim is only extended while the feed is still claimed until
+-- claimed_until, once its fetch rescheduled the feed it is left alone
+UPDATE rssfeeds
+SET next_fetch_at = sqlc.arg(renewed_until)::timestamp
+WHERE id = sqlc.arg(id)
+AND next_fetch_at = sqlc.arg(claimed_until)::timestamp;
+
+-- name: MoveFeed :exec
+WITH old_feed AS (
+    SELECT id, url FROM rssfeeds WHERE rssfeeds.id = $1
+), alias AS (
+    INSERT INTO feed_aliases (url, created_at, feed_id)
+    SELECT old_feed.url, NOW(), old_feed.id FROM old_feed
+    ON CONFLICT (url) DO NOTHING
+), moved_back AS (
+    DELETE FROM feed_aliases WHERE feed_aliases.url = $2
+)
+UPDATE rssfeeds
+SET url = $2,
+updated_at = NOW()
+WHERE rssfeeds.id = $1;
+
+-- name: SetFeedFullArticle :exec
+UPDATE rssfeeds
+SET fetch_full_article = $2,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetFeedNextFetch :exec
+UPDATE rssfeeds
+SET next_fetch_at = $2,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedCache :exec
+UPDATE rssfeeds
+SET etag = $2,
+last_modified = $3,
+updated_at = NOW()
+WHERE id = $1;
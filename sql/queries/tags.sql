@@ -0,0 +1,38 @@
+-- name: CreateTag :one
+INSERT INTO tags (id, created_at, name)
+VALUES (
+    $1,
+    $2,
+    $3
+    )
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+--
+
+-- name: AddPostTag :exec
+INSERT INTO post_tags (post_id, tag_id)
+VALUES (
+    $1,
+    $2
+    )
+ON CONFLICT DO NOTHING;
+--
+
+-- name: GetTagsForPost :many
+SELECT tags.name
+FROM tags
+INNER JOIN post_tags ON post_tags.tag_id = tags.id
+WHERE post_tags.post_id = $1
+ORDER BY tags.name;
+--
+
+-- name: GetTagsForUser :many
+SELECT tags.name, COUNT(DISTINCT post_tags.post_id) AS post_count
+FROM tags
+INNER JOIN post_tags ON post_tags.tag_id = tags.id
+INNER JOIN posts ON post_tags.post_id = posts.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+GROUP BY tags.name
+ORDER BY post_count DESC, tags.name;
+--
@@ -0,0 +1,52 @@
+-- name: SetFeedHub :exec
+INSERT INTO websub_subscriptions (feed_id, created_at, updated_at, hub, topic)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+    )
+ON CONFLICT (feed_id) DO UPDATE
+SET hub = EXCLUDED.hub,
+topic = EXCLUDED.topic,
+updated_at = EXCLUDED.updated_at,
+requested_at = NULL,
+lease_expires_at = NULL
+WHERE websub_subscriptions.hub <> EXCLUDED.hub
+OR websub_subscriptions.topic <> EXCLUDED.topic;
+--
+
+-- name: DeleteFeedHub :exec
+DELETE FROM websub_subscriptions
+WHERE feed_id = $1;
+--
+
+-- name: GetSubscription :one
+SELECT feed_id, created_at, updated_at, hub, topic, secret, requested_at, lease_expires_at, callback
+FROM websub_subscriptions
+WHERE feed_id = $1;
+--
+
+-- name: GetSubscriptionsToRenew :many
+SELECT feed_id, created_at, updated_at, hub, topic, secret, requested_at, lease_expires_at, callback
+FROM websub_subscriptions
+WHERE (lease_expires_at IS NULL OR lease_expires_at < sqlc.arg(renew_before)::timestamp)
+AND (requested_at IS NULL OR requested_at < sqlc.arg(retry_before)::timestamp)
+ORDER BY lease_expires_at ASC NULLS FIRST;
+--
+
+-- name: MarkSubscriptionRequested :exec
+UPDATE websub_subscriptions
+SET secret = sqlc.arg(secret),
+requested_at = sqlc.arg(requested_at)::timestamp,
+callback = sqlc.arg(callback),
+updated_at = NOW()
+WHERE feed_id = sqlc.arg(feed_id);
+--
+
+-- name: SetSubscriptionLease :exec
+UPDATE websub_subscriptions
+SET lease_expires_at = $2,
+updated_at = NOW()
+WHERE feed_id = $1;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE rssfeeds
+ADD COLUMN etag TEXT,
+ADD COLUMN last_modified TEXT;
+
+-- +goose Down
+ALTER TABLE rssfeeds
+DROP COLUMN etag,
+DROP COLUMN last_modified;
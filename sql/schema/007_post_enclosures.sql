@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE post_enclosures (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  post_id UUID NOT NULL,
+  url TEXT NOT NULL,
+  length BIGINT,
+  mime_type TEXT,
+  duration TEXT,
+  episode INTEGER,
+  season INTEGER,
+  episode_type TEXT,
+  UNIQUE (post_id, url),
+  CONSTRAINT fk_post_id
+  FOREIGN KEY (post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS
+idx_post_enclosures_post_id
+ON post_enclosures(post_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_post_enclosures_post_id;
+DROP TABLE post_enclosures;
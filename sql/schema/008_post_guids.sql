@@ -0,0 +1,36 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN guid TEXT;
+
+-- posts stored before guids were tracked are identified by their link
+UPDATE posts
+SET guid = url
+WHERE guid IS NULL;
+
+ALTER TABLE posts
+ALTER COLUMN guid SET NOT NULL;
+
+ALTER TABLE posts
+DROP CONSTRAINT IF EXISTS posts_url_key;
+
+ALTER TABLE posts
+ADD CONSTRAINT posts_feed_id_guid_key UNIQUE (feed_id, guid);
+
+-- +goose Down
+ALTER TABLE posts
+DROP CONSTRAINT IF EXISTS posts_feed_id_guid_key;
+
+-- the url was unique before, keep a single post per url
+DELETE FROM posts a
+USING posts b
+WHERE a.url = b.url
+AND a.created_at > b.created_at;
+
+DELETE FROM posts
+WHERE url = '';
+
+ALTER TABLE posts
+ADD CONSTRAINT posts_url_key UNIQUE (url);
+
+ALTER TABLE posts
+DROP COLUMN guid;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE tags (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE post_tags (
+  post_id UUID NOT NULL,
+  tag_id UUID NOT NULL,
+  PRIMARY KEY (post_id, tag_id),
+  CONSTRAINT fk_post_id
+  FOREIGN KEY (post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE,
+  CONSTRAINT fk_tag_id
+  FOREIGN KEY (tag_id)
+  REFERENCES tags(id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS
+idx_post_tags_tag_id
+ON post_tags(tag_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_post_tags_tag_id;
+DROP TABLE post_tags;
+DROP TABLE tags;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE rssfeeds
+ADD COLUMN next_fetch_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE rssfeeds
+DROP COLUMN next_fetch_at;
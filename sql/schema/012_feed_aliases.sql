@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE feed_aliases (
+  url TEXT PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  feed_id UUID NOT NULL,
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES rssfeeds(id)
+  ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_aliases;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE rssfeeds
+ADD COLUMN fetch_full_article BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE posts
+ADD COLUMN article TEXT;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN article;
+
+ALTER TABLE rssfeeds
+DROP COLUMN fetch_full_article;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE feed_scrapers (
+  feed_id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  item_selector TEXT NOT NULL,
+  title_selector TEXT NOT NULL,
+  link_selector TEXT NOT NULL DEFAULT '',
+  date_selector TEXT NOT NULL DEFAULT '',
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES rssfeeds(id)
+  ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_scrapers;
@@ -0,0 +1,42 @@
+-- +goose Up
+ALTER TABLE rssfeeds
+ADD COLUMN source_type TEXT NOT NULL DEFAULT '',
+ADD COLUMN source_config JSONB NOT NULL DEFAULT '{}';
+
+-- scraped feeds become feeds of the scrape source type
+UPDATE rssfeeds
+SET source_type = 'scrape',
+source_config = JSONB_STRIP_NULLS(JSONB_BUILD_OBJECT(
+    'item', feed_scrapers.item_selector,
+    'title', feed_scrapers.title_selector,
+    'link', NULLIF(feed_scrapers.link_selector, ''),
+    'date', NULLIF(feed_scrapers.date_selector, '')
+))
+FROM feed_scrapers
+WHERE feed_scrapers.feed_id = rssfeeds.id;
+
+DROP TABLE feed_scrapers;
+
+-- +goose Down
+CREATE TABLE feed_scrapers (
+  feed_id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  item_selector TEXT NOT NULL,
+  title_selector TEXT NOT NULL,
+  link_selector TEXT NOT NULL DEFAULT '',
+  date_selector TEXT NOT NULL DEFAULT '',
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES rssfeeds(id)
+  ON DELETE CASCADE
+);
+
+INSERT INTO feed_scrapers (feed_id, created_at, updated_at, item_selector, title_selector, link_selector, date_selector)
+SELECT id, NOW(), NOW(), source_config->>'item', source_config->>'title', COALESCE(source_config->>'link', ''), COALESCE(source_config->>'date', '')
+FROM rssfeeds
+WHERE source_type = 'scrape';
+
+ALTER TABLE rssfeeds
+DROP COLUMN source_config,
+DROP COLUMN source_type;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- the unique (post_id, url) constraint already indexes post_id
+DROP INDEX IF EXISTS idx_post_enclosures_post_id;
+
+-- +goose Down
+CREATE INDEX IF NOT EXISTS
+idx_post_enclosures_post_id
+ON post_enclosures(post_id);
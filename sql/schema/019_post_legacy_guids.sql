@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN legacy_guid BOOLEAN NOT NULL DEFAULT false;
+
+-- posts stored before guids were tracked were given their link as guid, they
+-- are matched by it until their item is fetched again and gives them its
+-- guid. Posts of items without a guid are keyed by their link too, and are
+-- adopted the same way if their feed starts giving guids
+UPDATE posts
+SET legacy_guid = true
+WHERE guid = url
+AND url <> '';
+
+CREATE INDEX idx_posts_legacy_guid ON posts (feed_id, url) WHERE legacy_guid;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_posts_legacy_guid;
+
+ALTER TABLE posts
+DROP COLUMN legacy_guid;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- the callback the hub was last asked to push to, the subscription is
+-- cancelled with it when the feed changes or drops its hub
+ALTER TABLE websub_subscriptions
+ADD COLUMN callback TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE websub_subscriptions
+DROP COLUMN callback;
@@ -1,8 +1,11 @@
 package test
 
+import "github.com/ManoloEsS/gator_cli/internal/config"
+
 type MockCfg struct {
 	CurrentUser string
 	SetUserErr  error
+	FeedLimits  config.FeedLimits
 }
 
 func (m *MockCfg) SetUser(name string) error {
@@ -16,3 +19,7 @@ func (m *MockCfg) SetUser(name string) error {
 func (m *MockCfg) GetCurrentUser() string {
 	return m.CurrentUser
 }
+
+func (m *MockCfg) GetFeedLimits() config.FeedLimits {
+	return m.FeedLimits
+}
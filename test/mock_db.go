@@ -2,22 +2,41 @@ package test
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"sync"
 
 	"github.com/ManoloEsS/gator_cli/internal/database"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // Mock implementations for testing
 type MockDb struct {
 	Users       map[string]database.User
-	CreateError error
-	ResetError  error
+	Feeds       map[string]database.Rssfeed
+	FeedAliases map[string]uuid.UUID
+	Posts       []database.Post
+	Enclosures  []database.PostEnclosure
+	Tags        map[string]database.Tag
+	PostTags    []database.PostTag
+	FeedFollows []database.FeedFollow
+	// Subscriptions are the WebSub subscriptions by feed id
+	Subscriptions map[uuid.UUID]database.WebsubSubscription
+	CreateError   error
+	GetFeedError  error
+	ResetError    error
+	// mu guards the methods the aggregator workers call concurrently
+	mu sync.Mutex
 }
 
 func NewMockDb() *MockDb {
 	return &MockDb{
-		Users: make(map[string]database.User),
+		Users:         make(map[string]database.User),
+		Feeds:         make(map[string]database.Rssfeed),
+		FeedAliases:   make(map[string]uuid.UUID),
+		Tags:          make(map[string]database.Tag),
+		Subscriptions: make(map[uuid.UUID]database.WebsubSubscription),
 	}
 }
 
@@ -64,9 +83,35 @@ func (m *MockDb) ResetUsers(ctx context.Context) error {
 	return nil
 }
 
-// TODO:finish function
-func (m *MockDb) CreateRSSFeed(ctx context.Context, arg database.CreateRSSFeedParams) (database.Rssfeed, error) {
-	return database.Rssfeed{}, nil
+func (m *MockDb) CreateRSSFeed(ctx context.Context, arg database.CreateRSSFeedParams) (database.CreateRSSFeedRow, error) {
+	if m.CreateError != nil {
+		return database.CreateRSSFeedRow{}, m.CreateError
+	}
+	if _, exists := m.Feeds[arg.Url]; exists {
+		return database.CreateRSSFeedRow{}, errors.New("feed already exists")
+	}
+	m.Feeds[arg.Url] = database.Rssfeed{
+		ID:               arg.ID,
+		CreatedAt:        arg.CreatedAt,
+		UpdatedAt:        arg.UpdatedAt,
+		Name:             arg.Name,
+		Url:              arg.Url,
+		UserID:           arg.UserID,
+		FetchFullArticle: arg.FetchFullArticle,
+		SourceType:       arg.SourceType,
+		SourceConfig:     arg.SourceConfig,
+	}
+	return database.CreateRSSFeedRow{
+		ID:               arg.ID,
+		CreatedAt:        arg.CreatedAt,
+		UpdatedAt:        arg.UpdatedAt,
+		Name:             arg.Name,
+		Url:              arg.Url,
+		UserID:           arg.UserID,
+		FetchFullArticle: arg.FetchFullArticle,
+		SourceType:       arg.SourceType,
+		SourceConfig:     arg.SourceConfig,
+	}, nil
 }
 
 // TODO:finish test function
@@ -75,10 +120,66 @@ func (m *MockDb) GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error) {
 }
 
 func (m *MockDb) GetFeedByUrl(ctx context.Context, url string) (database.Rssfeed, error) {
-	return database.Rssfeed{}, nil
+	if m.GetFeedError != nil {
+		return database.Rssfeed{}, m.GetFeedError
+	}
+	feed, exists := m.Feeds[url]
+	if exists {
+		return feed, nil
+	}
+	if feedID, aliased := m.FeedAliases[url]; aliased {
+		for _, feed := range m.Feeds {
+			if feed.ID == feedID {
+				return feed, nil
+			}
+		}
+	}
+	return database.Rssfeed{}, sql.ErrNoRows
+}
+
+func (m *MockDb) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Rssfeed, error) {
+	for _, feed := range m.Feeds {
+		if feed.ID == id {
+			return feed, nil
+		}
+	}
+	return database.Rssfeed{}, sql.ErrNoRows
+}
+
+func (m *MockDb) GetFeedsByName(ctx context.Context, name string) ([]database.Rssfeed, error) {
+	feeds := []database.Rssfeed{}
+	for _, feed := range m.Feeds {
+		if feed.Name == name {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds, nil
+}
+
+func (m *MockDb) GetFollowedFeedsByName(ctx context.Context, arg database.GetFollowedFeedsByNameParams) ([]database.Rssfeed, error) {
+	feeds := []database.Rssfeed{}
+	for _, feed := range m.Feeds {
+		if feed.Name != arg.Name {
+			continue
+		}
+		for _, follow := range m.FeedFollows {
+			if follow.UserID == arg.UserID && follow.FeedID == feed.ID {
+				feeds = append(feeds, feed)
+				break
+			}
+		}
+	}
+	return feeds, nil
 }
 
 func (m *MockDb) CreateFeedFollow(ctx context.Context, args database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	m.FeedFollows = append(m.FeedFollows, database.FeedFollow{
+		ID:        args.ID,
+		CreatedAt: args.CreatedAt,
+		UpdatedAt: args.UpdatedAt,
+		UserID:    args.UserID,
+		FeedID:    args.FeedID,
+	})
 	return database.CreateFeedFollowRow{}, nil
 }
 
@@ -89,3 +190,376 @@ func (m *MockDb) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([
 func (m *MockDb) UnfollowFeed(ctx context.Context, arg database.UnfollowFeedParams) error {
 	return nil
 }
+
+func (m *MockDb) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return nil
+}
+
+// ClaimNextFeedToFetch returns the due feed fetched the longest time ago,
+// leaving out feeds with an active WebSub lease, and pushes its next fetch
+// to ClaimedUntil, like the query does
+func (m *MockDb) ClaimNextFeedToFetch(ctx context.Context, arg database.ClaimNextFeedToFetchParams) (database.Rssfeed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var next database.Rssfeed
+	found := false
+	for _, feed := range m.Feeds {
+		if feed.NextFetchAt.Valid && feed.NextFetchAt.Time.After(arg.Now) {
+			continue
+		}
+		if sub, exists := m.Subscriptions[feed.ID]; exists && sub.LeaseExpiresAt.Valid && sub.LeaseExpiresAt.Time.After(arg.Now) {
+			continue
+		}
+		if !found || !feed.LastFetchedAt.Valid ||
+			(next.LastFetchedAt.Valid && feed.LastFetchedAt.Time.Before(next.LastFetchedAt.Time)) {
+			next = feed
+			found = true
+		}
+	}
+	if !found {
+		return database.Rssfeed{}, sql.ErrNoRows
+	}
+
+	claimed := next
+	claimed.NextFetchAt = sql.NullTime{Time: arg.ClaimedUntil, Valid: true}
+	m.Feeds[claimed.Url] = claimed
+	return next, nil
+}
+
+// RenewFeedClaim pushes the next fetch of the feed to RenewedUntil while it
+// is still ClaimedUntil, like the query does
+func (m *MockDb) RenewFeedClaim(ctx context.Context, arg database.RenewFeedClaimParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for feedUrl, feed := range m.Feeds {
+		if feed.ID != arg.ID || !feed.NextFetchAt.Valid || !feed.NextFetchAt.Time.Equal(arg.ClaimedUntil) {
+			continue
+		}
+		feed.NextFetchAt = sql.NullTime{Time: arg.RenewedUntil, Valid: true}
+		m.Feeds[feedUrl] = feed
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m *MockDb) MoveFeed(ctx context.Context, arg database.MoveFeedParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for url, feed := range m.Feeds {
+		if feed.ID == arg.ID {
+			if _, exists := m.FeedAliases[url]; !exists {
+				m.FeedAliases[url] = feed.ID
+			}
+			delete(m.FeedAliases, arg.Url)
+			delete(m.Feeds, url)
+			feed.Url = arg.Url
+			m.Feeds[arg.Url] = feed
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *MockDb) SetFeedNextFetch(ctx context.Context, arg database.SetFeedNextFetchParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for url, feed := range m.Feeds {
+		if feed.ID == arg.ID {
+			feed.NextFetchAt = arg.NextFetchAt
+			m.Feeds[url] = feed
+		}
+	}
+	return nil
+}
+
+func (m *MockDb) SetFeedFullArticle(ctx context.Context, arg database.SetFeedFullArticleParams) error {
+	for url, feed := range m.Feeds {
+		if feed.ID == arg.ID {
+			feed.FetchFullArticle = arg.FetchFullArticle
+			m.Feeds[url] = feed
+		}
+	}
+	return nil
+}
+
+func (m *MockDb) UpdateFeedCache(ctx context.Context, arg database.UpdateFeedCacheParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for url, feed := range m.Feeds {
+		if feed.ID == arg.ID {
+			feed.Etag = arg.Etag
+			feed.LastModified = arg.LastModified
+			m.Feeds[url] = feed
+		}
+	}
+	return nil
+}
+
+func (m *MockDb) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, post := range m.Posts {
+		if post.FeedID == arg.FeedID && post.Guid == arg.Guid {
+			return database.Post{}, &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint \"posts_feed_id_guid_key\""}
+		}
+	}
+	post := database.Post{
+		ID:                 arg.ID,
+		CreatedAt:          arg.CreatedAt,
+		UpdatedAt:          arg.UpdatedAt,
+		Title:              arg.Title,
+		Url:                arg.Url,
+		Description:        arg.Description,
+		PublishedAt:        arg.PublishedAt,
+		FeedID:             arg.FeedID,
+		Guid:               arg.Guid,
+		Author:             arg.Author,
+		Content:            arg.Content,
+		PublishedAtGuessed: arg.PublishedAtGuessed,
+	}
+	m.Posts = append(m.Posts, post)
+	return post, nil
+}
+
+func (m *MockDb) AdoptLegacyPost(ctx context.Context, arg database.AdoptLegacyPostParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var adopted int64
+	for i, post := range m.Posts {
+		if post.FeedID == arg.FeedID && post.Url == arg.Url && post.LegacyGuid {
+			m.Posts[i].Guid = arg.Guid
+			m.Posts[i].LegacyGuid = false
+			adopted++
+		}
+	}
+	return adopted, nil
+}
+
+func (m *MockDb) DeleteLegacyDuplicate(ctx context.Context, id uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, post := range m.Posts {
+		if post.ID != id {
+			continue
+		}
+		for _, legacy := range m.Posts {
+			if legacy.FeedID == post.FeedID && legacy.Url == post.Url && legacy.LegacyGuid {
+				m.Posts = append(m.Posts[:i], m.Posts[i+1:]...)
+				return 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+func (m *MockDb) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	return []database.GetPostsForUserRow{}, nil
+}
+
+// GetPostForUser doesn't check the follows of the user, the mock doesn't store them
+func (m *MockDb) GetPostForUser(ctx context.Context, arg database.GetPostForUserParams) (database.GetPostForUserRow, error) {
+	for _, post := range m.Posts {
+		if post.ID.String() != arg.Post && post.Url != arg.Post {
+			continue
+		}
+		row := database.GetPostForUserRow{
+			ID:                 post.ID,
+			CreatedAt:          post.CreatedAt,
+			UpdatedAt:          post.UpdatedAt,
+			Title:              post.Title,
+			Url:                post.Url,
+			Description:        post.Description,
+			PublishedAt:        post.PublishedAt,
+			FeedID:             post.FeedID,
+			Author:             post.Author,
+			Content:            post.Content,
+			PublishedAtGuessed: post.PublishedAtGuessed,
+			Article:            post.Article,
+		}
+		for _, feed := range m.Feeds {
+			if feed.ID == post.FeedID {
+				row.FeedName = feed.Name
+			}
+		}
+		return row, nil
+	}
+	return database.GetPostForUserRow{}, sql.ErrNoRows
+}
+
+func (m *MockDb) SetPostArticle(ctx context.Context, arg database.SetPostArticleParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, post := range m.Posts {
+		if post.ID == arg.ID {
+			m.Posts[i].Article = arg.Article
+		}
+	}
+	return nil
+}
+
+func (m *MockDb) CreatePostEnclosure(ctx context.Context, arg database.CreatePostEnclosureParams) (database.PostEnclosure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enclosure := database.PostEnclosure{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		PostID:      arg.PostID,
+		Url:         arg.Url,
+		Length:      arg.Length,
+		MimeType:    arg.MimeType,
+		Duration:    arg.Duration,
+		Episode:     arg.Episode,
+		Season:      arg.Season,
+		EpisodeType: arg.EpisodeType,
+	}
+	m.Enclosures = append(m.Enclosures, enclosure)
+	return enclosure, nil
+}
+
+func (m *MockDb) GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]database.PostEnclosure, error) {
+	enclosures := []database.PostEnclosure{}
+	for _, enclosure := range m.Enclosures {
+		if enclosure.PostID == postID {
+			enclosures = append(enclosures, enclosure)
+		}
+	}
+	return enclosures, nil
+}
+
+func (m *MockDb) GetEnclosuresForUser(ctx context.Context, arg database.GetEnclosuresForUserParams) ([]database.GetEnclosuresForUserRow, error) {
+	return []database.GetEnclosuresForUserRow{}, nil
+}
+
+func (m *MockDb) GetPostsForUserByTag(ctx context.Context, arg database.GetPostsForUserByTagParams) ([]database.GetPostsForUserByTagRow, error) {
+	return []database.GetPostsForUserByTagRow{}, nil
+}
+
+func (m *MockDb) CreateTag(ctx context.Context, arg database.CreateTagParams) (database.Tag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if tag, exists := m.Tags[arg.Name]; exists {
+		return tag, nil
+	}
+	tag := database.Tag{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		Name:      arg.Name,
+	}
+	m.Tags[arg.Name] = tag
+	return tag, nil
+}
+
+func (m *MockDb) AddPostTag(ctx context.Context, arg database.AddPostTagParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, postTag := range m.PostTags {
+		if postTag.PostID == arg.PostID && postTag.TagID == arg.TagID {
+			return nil
+		}
+	}
+	m.PostTags = append(m.PostTags, database.PostTag{PostID: arg.PostID, TagID: arg.TagID})
+	return nil
+}
+
+func (m *MockDb) GetTagsForPost(ctx context.Context, postID uuid.UUID) ([]string, error) {
+	names := []string{}
+	for _, postTag := range m.PostTags {
+		if postTag.PostID != postID {
+			continue
+		}
+		for _, tag := range m.Tags {
+			if tag.ID == postTag.TagID {
+				names = append(names, tag.Name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func (m *MockDb) GetTagsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetTagsForUserRow, error) {
+	return []database.GetTagsForUserRow{}, nil
+}
+
+func (m *MockDb) SetFeedHub(ctx context.Context, arg database.SetFeedHubParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, exists := m.Subscriptions[arg.FeedID]
+	if exists && sub.Hub == arg.Hub && sub.Topic == arg.Topic {
+		return nil
+	}
+	if !exists {
+		sub = database.WebsubSubscription{FeedID: arg.FeedID, CreatedAt: arg.CreatedAt}
+	}
+	sub.UpdatedAt = arg.UpdatedAt
+	sub.Hub = arg.Hub
+	sub.Topic = arg.Topic
+	sub.RequestedAt = sql.NullTime{}
+	sub.LeaseExpiresAt = sql.NullTime{}
+	m.Subscriptions[arg.FeedID] = sub
+	return nil
+}
+
+func (m *MockDb) DeleteFeedHub(ctx context.Context, feedID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.Subscriptions, feedID)
+	return nil
+}
+
+func (m *MockDb) GetSubscription(ctx context.Context, feedID uuid.UUID) (database.WebsubSubscription, error) {
+	sub, exists := m.Subscriptions[feedID]
+	if !exists {
+		return database.WebsubSubscription{}, sql.ErrNoRows
+	}
+	return sub, nil
+}
+
+func (m *MockDb) GetSubscriptionsToRenew(ctx context.Context, arg database.GetSubscriptionsToRenewParams) ([]database.WebsubSubscription, error) {
+	subs := []database.WebsubSubscription{}
+	for _, sub := range m.Subscriptions {
+		if sub.LeaseExpiresAt.Valid && !sub.LeaseExpiresAt.Time.Before(arg.RenewBefore) {
+			continue
+		}
+		if sub.RequestedAt.Valid && !sub.RequestedAt.Time.Before(arg.RetryBefore) {
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+func (m *MockDb) MarkSubscriptionRequested(ctx context.Context, arg database.MarkSubscriptionRequestedParams) error {
+	if sub, exists := m.Subscriptions[arg.FeedID]; exists {
+		sub.Secret = arg.Secret
+		sub.RequestedAt = sql.NullTime{Time: arg.RequestedAt, Valid: true}
+		sub.Callback = arg.Callback
+		m.Subscriptions[arg.FeedID] = sub
+	}
+	return nil
+}
+
+func (m *MockDb) SetSubscriptionLease(ctx context.Context, arg database.SetSubscriptionLeaseParams) error {
+	if sub, exists := m.Subscriptions[arg.FeedID]; exists {
+		sub.LeaseExpiresAt = arg.LeaseExpiresAt
+		m.Subscriptions[arg.FeedID] = sub
+	}
+	return nil
+}